@@ -2,9 +2,9 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"net"
 	"net/http"
 	"os"
 	"runtime"
@@ -12,15 +12,20 @@ import (
 
 	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
 	"github.com/cloudflare/lockbox/pkg/flagvar"
+	"github.com/cloudflare/lockbox/pkg/keyring"
+	"github.com/cloudflare/lockbox/pkg/keyring/pkcs11"
+	"github.com/cloudflare/lockbox/pkg/keyring/vault"
 	lockboxcontroller "github.com/cloudflare/lockbox/pkg/lockbox-controller"
 	server "github.com/cloudflare/lockbox/pkg/lockbox-server"
 	"github.com/cloudflare/lockbox/pkg/statemetrics"
 	"github.com/go-logr/zerologr"
-	"github.com/kevinburke/nacl"
 	"github.com/rs/zerolog"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -29,24 +34,58 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 var (
-	pubKey, priKey nacl.Key
-	version        = "dev"
-	syncPeriod     = 1 * time.Hour
-	keypairPath    = flagvar.File{Value: "/etc/lockbox/keypair.yaml"}
-	metricsAddr    = flagvar.TCPAddr{Text: ":8080"}
-	httpAddr       = flagvar.TCPAddr{Text: ":8081"}
+	version          = "dev"
+	syncPeriod       = 1 * time.Hour
+	keySource        = flagvar.Enum{Choices: []string{"file", "secret", "pkcs11", "vault"}, Value: "file"}
+	keypairPath      = flagvar.File{Value: "/etc/lockbox/keypair.yaml"}
+	keypairNamespace = "lockbox"
+	rotateEvery      time.Duration
+	pkcs11Config     pkcs11.Config
+	vaultConfig      = vault.Config{Address: os.Getenv("VAULT_ADDR"), Namespace: os.Getenv("VAULT_NAMESPACE")}
+	keyRetention     time.Duration
+	publishKeys      = true
+	leaderElect      = true
+	metricsAddr      = flagvar.TCPAddr{Text: ":8080"}
+	httpAddr         = flagvar.TCPAddr{Text: ":8081"}
+	tlsCertFile      = flagvar.File{}
+	tlsKeyFile       = flagvar.File{}
+
+	metricLabelsAllowlist      = flagvar.Strings{Value: []string{"*"}}
+	metricLabelsDenylist       = flagvar.Strings{}
+	metricAnnotationsAllowlist = flagvar.Strings{}
 )
 
 func main() {
-	flag.Var(&keypairPath, "keypair", fmt.Sprintf("public/private 32 byte keypairs (%s)", keypairPath.Help()))
+	flag.Var(&keySource, "key-source", fmt.Sprintf("where keypairs are loaded from (%s)", keySource.Help()))
+	flag.Var(&keypairPath, "keypair", fmt.Sprintf("public/private 32 byte keypairs, for the file key source (%s)", keypairPath.Help()))
+	flag.StringVar(&keypairNamespace, "keypair-namespace", keypairNamespace, "namespace of keypair Secrets, for the secret key source")
+	flag.StringVar(&pkcs11Config.Module, "pkcs11-module", "", "path to the PKCS#11 module, for the pkcs11 key source")
+	flag.StringVar(&pkcs11Config.TokenLabel, "pkcs11-token", "", "label of the PKCS#11 token holding the keypair, for the pkcs11 key source")
+	flag.StringVar(&pkcs11Config.KeyLabel, "pkcs11-key", "lockbox", "label of the PKCS#11 keypair, for the pkcs11 key source")
+	flag.StringVar(&vaultConfig.Address, "vault-addr", vaultConfig.Address, "Vault server URL, for the vault key source")
+	flag.StringVar(&vaultConfig.Namespace, "vault-namespace", vaultConfig.Namespace, "Vault Enterprise namespace, for the vault key source")
+	flag.StringVar(&vaultConfig.Role, "vault-role", "lockbox-controller", "Vault Kubernetes auth role, for the vault key source")
+	flag.StringVar(&vaultConfig.AuthMount, "vault-auth-mount", "kubernetes", "Vault Kubernetes auth mount path, for the vault key source")
+	flag.StringVar(&vaultConfig.TransitMount, "vault-transit-mount", "transit", "Vault transit secrets engine mount path, for the vault key source")
+	flag.StringVar(&vaultConfig.TransitKey, "vault-transit-key", "lockbox", "Vault transit key wrapping the private key, for the vault key source")
+	flag.DurationVar(&rotateEvery, "rotate-every", rotateEvery, "generate a new keypair this often, for the secret key source (0 disables rotation)")
+	flag.DurationVar(&keyRetention, "key-retention", keyRetention, "delete rotated keypairs this long after being superseded (0 keeps them indefinitely)")
+	flag.BoolVar(&publishKeys, "publish-keys", publishKeys, "publish public keys, including each namespace's key, as cluster-scoped LockboxKey resources, readable by locket without access to the service proxy")
+	flag.BoolVar(&leaderElect, "leader-elect", leaderElect, "elect a leader among replicas to reconcile Lockboxes and manage keypairs")
 	flag.Var(&metricsAddr, "metrics-addr", fmt.Sprintf("bind for HTTP metrics (%s)", metricsAddr.Help()))
 	flag.Var(&httpAddr, "http-addr", fmt.Sprintf("bind for HTTP server (%s)", httpAddr.Help()))
+	flag.Var(&tlsCertFile, "tls-cert-file", fmt.Sprintf("certificate for serving the HTTP server over TLS, reloaded when changed (%s)", tlsCertFile.Help()))
+	flag.Var(&tlsKeyFile, "tls-key-file", fmt.Sprintf("private key for --tls-cert-file (%s)", tlsKeyFile.Help()))
 	flag.DurationVar(&syncPeriod, "sync-period", syncPeriod, "controller sync period")
+	flag.Var(&metricLabelsAllowlist, "metric-labels-allowlist", fmt.Sprintf("Lockbox label keys exported in kube_lockbox_labels, or '*' for all (%s)", metricLabelsAllowlist.Help()))
+	flag.Var(&metricLabelsDenylist, "metric-labels-denylist", fmt.Sprintf("Lockbox label keys never exported in kube_lockbox_labels (%s)", metricLabelsDenylist.Help()))
+	flag.Var(&metricAnnotationsAllowlist, "metric-annotations-allowlist", fmt.Sprintf("Lockbox annotation keys exported in kube_lockbox_annotations, or '*' for all (%s)", metricAnnotationsAllowlist.Help()))
 	flag.String("v", "", "log level for V logs")
 	flag.Parse()
 
@@ -58,19 +97,17 @@ func main() {
 	logf.SetLogger(zerologr.New(&zl))
 	logger := zl.With().Str("name", "main").Logger()
 
-	keypair, err := os.Open(keypairPath.Value)
-	if err != nil {
-		logger.Fatal().Err(err).Str("path", keypairPath.Value).Msg("unable to open keypair")
+	if (tlsCertFile.Value == "") != (tlsKeyFile.Value == "") {
+		logger.Fatal().Msg("--tls-cert-file and --tls-key-file must be set together")
 		os.Exit(1)
 	}
-	pubKey, priKey, err = KeyPairFromYAMLOrJSON(keypair)
-	if err != nil {
-		logger.Fatal().Err(err).Str("path", keypairPath.Value).Msg("unable to parse keypair")
+
+	if rotateEvery > 0 && keySource.Value != "secret" {
+		logger.Fatal().Msg("--rotate-every requires --key-source=secret")
 		os.Exit(1)
 	}
-	keypair.Close()
 
-	err = lockboxv1.AddToScheme(scheme.Scheme)
+	err := lockboxv1.AddToScheme(scheme.Scheme)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("unable to add lockbox schemes")
 		os.Exit(1)
@@ -84,6 +121,55 @@ func main() {
 		os.Exit(1)
 	}
 
+	ctx := signals.SetupSignalHandler()
+	keys := keyring.New()
+	var fileSource *keyring.FileSource
+	var secretSource *keyring.SecretSource
+	var transit *vault.Transit
+
+	switch keySource.Value {
+	case "file", "vault":
+		var options []keyring.FileSourceOption
+		if keySource.Value == "vault" {
+			// Tokens are read from the environment rather than a flag, to keep them out of
+			// the process's arguments. Without one, the controller uses Kubernetes auth.
+			vaultConfig.Token = os.Getenv("VAULT_TOKEN")
+			transit = vault.NewTransit(vaultConfig)
+			options = append(options, keyring.WithUnwrapper(transit))
+		}
+
+		fileSource = keyring.NewFileSource(keys, keypairPath.Value, options...)
+		if err := fileSource.Load(ctx); err != nil {
+			logger.Fatal().Err(err).Str("path", keypairPath.Value).Msg("unable to load keypair")
+			os.Exit(1)
+		}
+	case "secret":
+		// The manager's cache isn't started yet, so keypairs are read directly from the API.
+		c, err := crclient.New(cfg, crclient.Options{Scheme: scheme.Scheme})
+		if err != nil {
+			logger.Fatal().Err(err).Msg("unable to create keypair client")
+			os.Exit(1)
+		}
+
+		// Only the leader bootstraps the first keypair, once the manager starts. Until then,
+		// replicas start without keypairs and load it when the keypair Secret is created.
+		secretSource = keyring.NewSecretSource(keys, c, keypairNamespace)
+		if err := secretSource.Load(ctx); err != nil && !errors.Is(err, keyring.ErrNoKeyPairs) {
+			logger.Fatal().Err(err).Str("namespace", keypairNamespace).Msg("unable to load keypairs")
+			os.Exit(1)
+		}
+	case "pkcs11":
+		pkcs11Config.PIN = os.Getenv("LOCKBOX_PKCS11_PIN")
+		key, err := pkcs11.Open(pkcs11Config)
+		if err != nil {
+			logger.Fatal().Err(err).Str("module", pkcs11Config.Module).Str("token", pkcs11Config.TokenLabel).Msg("unable to open PKCS#11 key")
+			os.Exit(1)
+		}
+		defer key.Close()
+
+		keys.Replace(keyring.KeyPair{Public: key.PublicKey(), Private: key})
+	}
+
 	mgr, err := manager.New(cfg, manager.Options{
 		Metrics: metricsserver.Options{
 			BindAddress: metricsAddr.Text,
@@ -91,7 +177,10 @@ func main() {
 		Cache: cache.Options{
 			SyncPeriod: &syncPeriod,
 		},
-		Scheme: scheme.Scheme,
+		Scheme:                        scheme.Scheme,
+		LeaderElection:                leaderElect,
+		LeaderElectionID:              "lockbox-controller.lockbox.k8s.cloudflare.com",
+		LeaderElectionReleaseOnCancel: true,
 	})
 	if err != nil {
 		logger.Fatal().Err(err).Msg("unable to create controller manager")
@@ -101,8 +190,6 @@ func main() {
 	recorder := mgr.GetEventRecorderFor("lockbox")
 	client := mgr.GetClient()
 
-	sr := lockboxcontroller.NewSecretReconciler(pubKey, priKey, lockboxcontroller.WithRecorder(recorder), lockboxcontroller.WithClient(client))
-
 	info := statemetrics.NewKubernetesVec(statemetrics.KubernetesOpts{
 		Name: "kube_lockbox_info",
 		Help: "Information about Lockbox",
@@ -127,12 +214,41 @@ func main() {
 		Name: "kube_lockbox_labels",
 		Help: "Kubernetes labels converted to Prometheus labels",
 	})
-	metrics.Registry.MustRegister(info, created, resourceVersion, lbType, labels, peerKey)
+	statusCondition := statemetrics.NewConditionsVec(statemetrics.KubernetesOpts{
+		Name: "kube_lockbox_status_condition",
+		Help: "The condition of a Lockbox",
+	}, []string{"namespace", "lockbox"})
+	lastUnlock := statemetrics.NewKubernetesVec(statemetrics.KubernetesOpts{
+		Name: "kube_lockbox_last_unlock_timestamp",
+		Help: "Unix timestamp of the last successful unlock",
+	}, []string{"namespace", "lockbox"})
+	dataKeys := statemetrics.NewKubernetesVec(statemetrics.KubernetesOpts{
+		Name: "kube_lockbox_data_keys",
+		Help: "Number of sealed data keys",
+	}, []string{"namespace", "lockbox"})
+	annotations := statemetrics.NewLabelsVec(statemetrics.KubernetesOpts{
+		Name: "kube_lockbox_annotations",
+		Help: "Kubernetes annotations converted to Prometheus labels",
+	})
+	metrics.Registry.MustRegister(info, created, resourceVersion, lbType, labels, peerKey, statusCondition, lastUnlock, dataKeys, annotations)
+	metrics.Registry.MustRegister(keyring.NewCollector(keys, client))
+
+	status := statemetrics.NewStatusRecorder(statusCondition, lastUnlock, dataKeys)
 
 	mh := statemetrics.NewStateMetricProxy(
 		&handler.EnqueueRequestForObject{},
 		info, created, resourceVersion,
 		lbType, peerKey, labels,
+		statemetrics.WithStatusRecorder(status),
+		statemetrics.WithLabelFilter(statemetrics.NewLabelFilter(metricLabelsAllowlist.Value, metricLabelsDenylist.Value)),
+		statemetrics.WithAnnotations(annotations, statemetrics.NewLabelFilter(metricAnnotationsAllowlist.Value, nil)),
+	)
+
+	sr := lockboxcontroller.NewSecretReconciler(nil, nil,
+		lockboxcontroller.WithKeyring(keys),
+		lockboxcontroller.WithRecorder(recorder),
+		lockboxcontroller.WithClient(client),
+		lockboxcontroller.WithStatusObserver(status),
 	)
 
 	c, err := controller.New("lockbox-controller", mgr, controller.Options{
@@ -154,45 +270,101 @@ func main() {
 		os.Exit(1)
 	}
 
-	// TODO(terin): make server implement Runnable
-	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
-		mux := http.NewServeMux()
-		mux.Handle("/v1/public", server.PublicKey(pubKey))
+	requeuer := lockboxcontroller.NewPeerKeyRequeuer(client, keys)
+	if err := mgr.Add(requeuer); err != nil {
+		logger.Fatal().Err(err).Msg("unable to add peer key requeuer")
+		os.Exit(1)
+	}
+
+	if err := c.Watch(requeuer.Source(), &handler.EnqueueRequestForObject{}); err != nil {
+		logger.Fatal().Err(err).Msg("unable to watch requeued Lockbox resources")
+		os.Exit(1)
+	}
+
+	if publishKeys {
+		publisher := keyring.NewPublisher(keys, client)
+		if err := mgr.Add(publisher); err != nil {
+			logger.Fatal().Err(err).Msg("unable to add public key publisher")
+			os.Exit(1)
+		}
 
-		ln, err := net.Listen("tcp", httpAddr.Text)
+		pc, err := controller.New("lockbox-key-publisher", mgr, controller.Options{
+			Reconciler: publisher,
+		})
 		if err != nil {
-			return err
+			logger.Fatal().Err(err).Msg("unable to create public key publisher controller")
+			os.Exit(1)
 		}
 
-		// sig.kubernetes.io/controller-runtime/pkg/internal/httpserver
-		s := http.Server{
-			Handler:           mux,
-			MaxHeaderBytes:    1 << 20,
-			IdleTimeout:       90 * time.Second,
-			ReadHeaderTimeout: 32 * time.Second,
+		// Namespace keys are published for every namespace, so every namespace created or
+		// deleted maps to the same request.
+		publishRequest := handler.EnqueueRequestsFromMapFunc(func(context.Context, crclient.Object) []reconcile.Request {
+			return []reconcile.Request{{}}
+		})
+		if err := pc.Watch(source.Kind(mgr.GetCache(), &corev1.Namespace{}), publishRequest); err != nil {
+			logger.Fatal().Err(err).Msg("unable to watch Namespace resources")
+			os.Exit(1)
 		}
+	}
 
-		idleConnsClosed := make(chan struct{})
-		go func() {
-			<-ctx.Done()
+	if fileSource != nil {
+		if err := mgr.Add(fileSource); err != nil {
+			logger.Fatal().Err(err).Msg("unable to add keypair file watcher")
+			os.Exit(1)
+		}
+	}
 
-			if err := s.Shutdown(context.Background()); err != nil {
-				logger.Err(err).Send()
-			}
-			close(idleConnsClosed)
-		}()
+	if secretSource != nil {
+		// Every replica reloads keypairs, as every replica serves public keys.
+		kc, err := controller.New("lockbox-keyring", mgr, controller.Options{
+			Reconciler:         secretSource,
+			NeedLeaderElection: ptr.To(false),
+		})
+		if err != nil {
+			logger.Fatal().Err(err).Msg("unable to create keyring controller")
+			os.Exit(1)
+		}
+
+		// All keypair Secrets are reloaded together, so every change maps to the same request.
+		keyringRequest := handler.EnqueueRequestsFromMapFunc(func(context.Context, crclient.Object) []reconcile.Request {
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: keypairNamespace}}}
+		})
+		if err := kc.Watch(source.Kind(mgr.GetCache(), &corev1.Secret{}), keyringRequest, predicate.NewPredicateFuncs(secretSource.IsKeyPairSecret)); err != nil {
+			logger.Fatal().Err(err).Msg("unable to watch keypair Secret resources")
+			os.Exit(1)
+		}
 
-		if err := s.Serve(ln); err != nil && err != http.ErrServerClosed {
-			return err
+		// The Rotator creates a keypair when none exist, so only bootstraps without rotation.
+		if rotateEvery > 0 {
+			if err := mgr.Add(keyring.NewRotator(secretSource, rotateEvery, keyRetention)); err != nil {
+				logger.Fatal().Err(err).Msg("unable to add keypair rotator")
+				os.Exit(1)
+			}
+		} else if err := mgr.Add(keyring.NewBootstrapper(secretSource)); err != nil {
+			logger.Fatal().Err(err).Msg("unable to add keypair bootstrapper")
+			os.Exit(1)
 		}
+	}
 
-		<-idleConnsClosed
-		return nil
-	})); err != nil {
+	mux := http.NewServeMux()
+	mux.Handle("/v1/public", server.PublicKey(keys))
+	mux.Handle("/v1/verify", server.Verify(sr))
+
+	var serverOptions []server.ServerOption
+	if tlsCertFile.Value != "" {
+		serverOptions = append(serverOptions, server.WithTLS(tlsCertFile.Value, tlsKeyFile.Value))
+	}
+	if err := mgr.Add(server.NewServer(httpAddr.Text, mux, serverOptions...)); err != nil {
 		logger.Fatal().Err(err).Msg("unable to add server runnable")
 	}
 
-	if err := mgr.Start(signals.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		logger.Fatal().Err(err).Send()
 	}
+
+	if transit != nil {
+		if err := transit.Revoke(context.Background()); err != nil {
+			logger.Error().Err(err).Msg("unable to revoke vault token")
+		}
+	}
 }
@@ -1,22 +1,330 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"github.com/cloudflare/lockbox/pkg/flagvar"
+	"github.com/cloudflare/lockbox/pkg/keyring"
+	"github.com/kevinburke/nacl"
 	"github.com/kevinburke/nacl/box"
+	"github.com/kevinburke/nacl/scalarmult"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
 )
 
 func main() {
-	lockboxPubKey, lockboxPriKey, err := box.GenerateKey(rand.Reader)
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		var err error
+		switch os.Args[1] {
+		case "pub":
+			err = pub(os.Args[2:])
+		case "validate":
+			err = validate(os.Args[2:])
+		case "split":
+			err = split(os.Args[2:])
+		case "combine":
+			err = combine(os.Args[2:])
+		default:
+			fmt.Fprintf(os.Stderr, "usage: %s [pub|validate|split|combine] [flags]\n", os.Args[0])
+			os.Exit(2)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[1], err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := generate(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+}
+
+// generate prints a new keypair, either as the keypair file read by the controller or
+// as a Secret manifest that can be mounted into the controller deployment.
+func generate(args []string) error {
+	output := flagvar.Enum{Choices: []string{"yaml", "json", "secret"}, Value: "yaml"}
+	var name, namespace, escrowHex string
+
+	fs := flag.NewFlagSet("lockbox-keypair", flag.ExitOnError)
+	fs.Var(&output, "o", fmt.Sprintf("output format (%s)", output.Help()))
+	fs.StringVar(&name, "name", "keypair", "name of the Secret manifest")
+	fs.StringVar(&namespace, "namespace", "lockbox", "namespace of the Secret manifest")
+	fs.StringVar(&escrowHex, "escrow-key", "", "also print the Secret manifest as a Lockbox sealed to this public key (32-bit hex)")
+	_ = fs.Parse(args)
+
+	if escrowHex != "" && output.String() != "secret" {
+		return errors.New("-escrow-key requires -o secret")
+	}
+
+	pubKey, priKey, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	mlkemSeed, err := keyring.GenerateMLKEMSeed()
+	if err != nil {
+		return err
+	}
+
+	var b []byte
+	switch output.String() {
+	case "yaml":
+		b, err = keyring.KeyPairToYAML(pubKey, priKey, mlkemSeed)
+	case "json":
+		b, err = keyring.KeyPairToJSON(pubKey, priKey, mlkemSeed)
+		b = append(b, '\n')
+	case "secret":
+		b, err = secretManifest(name, namespace, escrowHex, pubKey, priKey, mlkemSeed)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.Write(b)
+	return err
+}
+
+// secretManifest returns a keypair Secret manifest, followed by a Lockbox of the same
+// Secret sealed to escrowHex if set.
+func secretManifest(name, namespace, escrowHex string, pub, pri nacl.Key, mlkemSeed []byte) ([]byte, error) {
+	secret, err := keyring.NewKeyPairSecret(name, namespace, pub, pri, mlkemSeed)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := yaml.Marshal(secret)
+	if err != nil || escrowHex == "" {
+		return b, err
+	}
+
+	escrowKey, err := nacl.Load(escrowHex)
+	if err != nil {
+		return nil, fmt.Errorf("could not load -escrow-key: %w", err)
+	}
+
+	senderPub, senderPri, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	lb := lockboxv1.NewFromSecret(*secret, namespace, escrowKey, senderPub, senderPri)
+	lb.TypeMeta = metav1.TypeMeta{
+		APIVersion: lockboxv1.GroupVersion.String(),
+		Kind:       "Lockbox",
+	}
+
+	lbb, err := yaml.Marshal(lb)
+	if err != nil {
+		return nil, err
+	}
+
+	b = append(b, "---\n"...)
+	return append(b, lbb...), nil
+}
+
+// pub prints the public key for a private key, read from a keypair file or as a bare
+// base64 or hex encoded key.
+func pub(args []string) error {
+	input := flagvar.File{}
+	format := flagvar.Enum{Choices: []string{"hex", "base64", "age"}, Value: "hex"}
+
+	fs := flag.NewFlagSet("pub", flag.ExitOnError)
+	fs.Var(&input, "f", fmt.Sprintf("keypair file or private key, or stdin if unset (%s)", input.Help()))
+	fs.Var(&format, "format", fmt.Sprintf("public key format (%s)", format.Help()))
+	_ = fs.Parse(args)
+
+	r, closer, err := openInput(input.String())
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	pri, err := parsePrivateKey(data)
+	if err != nil {
+		return err
+	}
+	pubKey := scalarmult.Base(pri)
+
+	switch format.String() {
+	case "hex":
+		fmt.Fprintln(os.Stdout, hex.EncodeToString(pubKey[:]))
+	case "base64":
+		fmt.Fprintln(os.Stdout, base64.StdEncoding.EncodeToString(pubKey[:]))
+	case "age":
+		fmt.Fprintln(os.Stdout, lockboxv1.AgeRecipient(pubKey))
+	}
+	return nil
+}
+
+// parsePrivateKey reads a private key from a keypair file, or a single line holding the
+// base64 or hex encoded key.
+func parsePrivateKey(data []byte) (nacl.Key, error) {
+	line := strings.TrimSpace(string(data))
+	if !strings.ContainsAny(line, "\n:") {
+		if b, err := hex.DecodeString(line); err == nil && len(b) == nacl.KeySize {
+			return keyFromBytes(b), nil
+		}
+		if b, err := base64.StdEncoding.DecodeString(line); err == nil && len(b) == nacl.KeySize {
+			return keyFromBytes(b), nil
+		}
+		return nil, errors.New("private key is not a 32 byte hex or base64 encoded key")
+	}
+
+	return keyring.PrivateKeyFromYAMLOrJSON(bytes.NewReader(data))
+}
+
+func keyFromBytes(b []byte) nacl.Key {
+	key := new([nacl.KeySize]byte)
+	copy(key[:], b)
+	return key
+}
+
+// validate checks a keypair file, or a keypair Secret manifest, can be loaded by the
+// controller and that its public key matches its private key.
+func validate(args []string) error {
+	input := flagvar.File{}
+
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	fs.Var(&input, "f", fmt.Sprintf("keypair file or Secret manifest, or stdin if unset (%s)", input.Help()))
+	_ = fs.Parse(args)
+
+	r, closer, err := openInput(input.String())
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	var tm metav1.TypeMeta
+	if err := yaml.Unmarshal(data, &tm); err == nil && tm.Kind == "Secret" {
+		var secret corev1.Secret
+		if err := yaml.Unmarshal(data, &secret); err != nil {
+			return err
+		}
+
+		var ok bool
+		data, ok = secret.Data[keyring.SecretKey]
+		if !ok {
+			return fmt.Errorf("secret %s has no %s key", secret.Name, keyring.SecretKey)
+		}
+	}
+
+	pubKey, pri, _, err := keyring.KeyPairFromYAMLOrJSON(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if !nacl.Verify32(scalarmult.Base(pri), pubKey) {
+		return errors.New("public key does not match private key")
+	}
+
+	fmt.Fprintf(os.Stdout, "ok %s\n", keyring.Fingerprint(pubKey))
+	return nil
+}
+
+// split prints Shamir shares of a keypair file's private key, one per line.
+func split(args []string) error {
+	input := flagvar.File{}
+	var n, k int
+
+	fs := flag.NewFlagSet("split", flag.ExitOnError)
+	fs.Var(&input, "f", fmt.Sprintf("keypair file, or stdin if unset (%s)", input.Help()))
+	fs.IntVar(&n, "n", 5, "number of shares")
+	fs.IntVar(&k, "k", 3, "number of shares required to recover the private key")
+	_ = fs.Parse(args)
+
+	r, closer, err := openInput(input.String())
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	pub, pri, mlkemSeed, err := keyring.KeyPairFromYAMLOrJSON(r)
+	if err != nil {
+		return err
+	}
+
+	shares, err := keyring.SplitPrivateKey(pub, pri, mlkemSeed, n, k)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range shares {
+		fmt.Fprintln(os.Stdout, s)
+	}
+	return nil
+}
+
+// combine reconstructs a keypair file from Shamir shares, read one per line.
+func combine(args []string) error {
+	input := flagvar.File{}
+
+	fs := flag.NewFlagSet("combine", flag.ExitOnError)
+	fs.Var(&input, "f", fmt.Sprintf("file with one share per line, or stdin if unset (%s)", input.Help()))
+	_ = fs.Parse(args)
+
+	r, closer, err := openInput(input.String())
 	if err != nil {
-		panic(err)
+		return err
 	}
+	defer closer()
 
-	pub64 := base64.StdEncoding.EncodeToString(lockboxPubKey[:])
-	pri64 := base64.StdEncoding.EncodeToString(lockboxPriKey[:])
+	var shares []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		shares = append(shares, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
 
-	fmt.Fprintf(os.Stdout, "public:  %s\nprivate: %s\n", pub64, pri64)
+	pub, pri, mlkemSeed, err := keyring.CombinePrivateKey(shares)
+	if err != nil {
+		return err
+	}
+
+	b, err := keyring.KeyPairToYAML(pub, pri, mlkemSeed)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(b)
+	return err
+}
+
+// openInput opens the named file, or stdin if the name is empty.
+func openInput(name string) (io.Reader, func(), error) {
+	if name == "" {
+		return os.Stdin, func() {}, nil
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, func() { f.Close() }, nil
 }
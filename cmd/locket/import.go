@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"filippo.io/age"
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"github.com/cloudflare/lockbox/pkg/flagvar"
+	"github.com/cloudflare/lockbox/pkg/importer"
+	"github.com/kevinburke/nacl"
+	"github.com/kevinburke/nacl/box"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// unsealer recovers a Secret from a single document in another tool's format, locked
+// for the namespace if set.
+type unsealer func(doc []byte, namespace string) (*importer.Secret, error)
+
+// importMain implements "locket import", converting Secrets encrypted by other tools into
+// Lockboxes without writing plaintext to disk.
+func importMain(args []string) {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, "usage: %s import sealedsecrets|sops [flags] [file...]\n", os.Args[0])
+		os.Exit(2)
+	}
+	if len(args) == 0 {
+		usage()
+	}
+
+	var (
+		sealedSecretsKey = flagvar.File{}
+		ageIdentity      = flagvar.File{}
+		namespace        string
+	)
+
+	fs := flag.NewFlagSet("import "+args[0], flag.ExitOnError)
+	addCommonFlags(fs)
+	fs.StringVar(&namespace, "namespace", "", "namespace to lock the Secrets for, instead of their own")
+
+	switch args[0] {
+	case "sealedsecrets":
+		fs.Var(&sealedSecretsKey, "key", fmt.Sprintf("SealedSecrets controller private keys, as PEM or key Secrets (%s)", sealedSecretsKey.Help()))
+	case "sops":
+		fs.Var(&ageIdentity, "identity", fmt.Sprintf("age identity file, defaulting to $SOPS_AGE_KEY_FILE (%s)", ageIdentity.Help()))
+	default:
+		usage()
+	}
+	_ = fs.Parse(args[1:])
+
+	logger := newLogger()
+
+	if err := lockboxv1.AddToScheme(scheme.Scheme); err != nil {
+		logger.Fatal().Err(err).Msg("unable to add lockbox schemes")
+		os.Exit(1)
+	}
+
+	var unseal unsealer
+	switch args[0] {
+	case "sealedsecrets":
+		if sealedSecretsKey.String() == "" {
+			logger.Fatal().Msg("--key is required")
+			os.Exit(1)
+		}
+		b, err := os.ReadFile(sealedSecretsKey.String())
+		if err != nil {
+			logger.Fatal().Err(err).Msg("unable to read --key")
+			os.Exit(1)
+		}
+		keys, err := importer.ParseSealedSecretsKeys(b)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("unable to load --key")
+			os.Exit(1)
+		}
+		unseal = importer.NewSealedSecrets(keys...).Unseal
+	case "sops":
+		path := ageIdentity.String()
+		if path == "" {
+			path = os.Getenv("SOPS_AGE_KEY_FILE")
+		}
+		if path == "" {
+			logger.Fatal().Msg("--identity or $SOPS_AGE_KEY_FILE is required")
+			os.Exit(1)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("unable to open age identity")
+			os.Exit(1)
+		}
+		identities, err := age.ParseIdentities(f)
+		f.Close()
+		if err != nil {
+			logger.Fatal().Err(err).Msg("unable to load age identity")
+			os.Exit(1)
+		}
+		unseal = importer.NewSOPS(identities...).Unseal
+	}
+
+	docs, err := readDocuments(fs.Args())
+	if err != nil {
+		logger.Fatal().Err(err).Msg("unable to read input")
+		os.Exit(1)
+	}
+
+	i := &lockImporter{
+		cfg:      GetConfig(),
+		cf:       runtimeserializer.NewCodecFactory(scheme.Scheme),
+		peerKeys: map[string]nacl.Key{},
+	}
+	for n, doc := range docs {
+		secret, err := unseal(doc, namespace)
+		if err != nil {
+			logger.Fatal().Err(err).Int("document", n).Msg("unable to decrypt document")
+			os.Exit(1)
+		}
+
+		lb, err := i.lock(context.Background(), secret)
+		if err != nil {
+			logger.Fatal().Err(err).Str("secret", secret.Name).Msg("unable to lock Secret")
+			os.Exit(1)
+		}
+
+		if n > 0 && output.String() == "yaml" {
+			fmt.Fprintln(os.Stdout, "---")
+		}
+		if err := writeLockbox(os.Stdout, i.cf, lb); err != nil {
+			logger.Fatal().Err(err).Msg("unable to encode Lockbox")
+			os.Exit(1)
+		}
+	}
+}
+
+// lockImporter locks imported Secrets as Lockboxes, fetching the peer key for each
+// namespace once.
+type lockImporter struct {
+	cfg      clientcmd.ClientConfig
+	cf       runtimeserializer.CodecFactory
+	peerKeys map[string]nacl.Key
+}
+
+func (i *lockImporter) lock(ctx context.Context, secret *importer.Secret) (*lockboxv1.Lockbox, error) {
+	namespace := secret.Namespace
+	if namespace == "" {
+		namespace, _, _ = i.cfg.Namespace()
+	}
+
+	peerKey, ok := i.peerKeys[namespace]
+	if !ok {
+		var err error
+		if peerHex != "" {
+			peerKey, err = nacl.Load(peerHex)
+		} else {
+			peerKey, err = fetchPeerKey(ctx, i.cfg, namespace)
+		}
+		if err != nil {
+			return nil, err
+		}
+		i.peerKeys[namespace] = peerKey
+	}
+
+	pubKey, priKey, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	lb := lockboxv1.NewFromSecret(secret.Secret, namespace, peerKey, pubKey, priKey)
+	secret.Template(lb)
+	return lb, nil
+}
+
+// readDocuments reads each YAML or JSON document from the files, or from stdin if none
+// are provided.
+func readDocuments(files []string) ([][]byte, error) {
+	readers := []io.Reader{os.Stdin}
+	if len(files) > 0 {
+		readers = readers[:0]
+		for _, name := range files {
+			f, err := os.Open(name)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			readers = append(readers, f)
+		}
+	}
+
+	var docs [][]byte
+	for _, r := range readers {
+		yr := utilyaml.NewYAMLReader(bufio.NewReader(r))
+		for {
+			doc, err := yr.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if len(bytes.TrimSpace(doc)) == 0 {
+				continue
+			}
+			docs = append(docs, doc)
+		}
+	}
+
+	return docs, nil
+}
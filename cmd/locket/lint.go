@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cloudflare/lockbox/pkg/flagvar"
+	"github.com/cloudflare/lockbox/pkg/lint"
+)
+
+// lintMain implements "locket lint", checking Lockbox manifests without any keys. It exits
+// with a non-zero status if any errors are found.
+func lintMain(args []string) {
+	var (
+		config = flagvar.File{}
+		format = flagvar.Enum{Choices: []string{"json", "github"}, Value: "json"}
+	)
+
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	fs.Var(&config, "config", fmt.Sprintf("lint configuration, with path conventions and allowed peer keys for each environment (%s)", config.Help()))
+	fs.Var(&format, "o", fmt.Sprintf("output format, where github prints workflow annotations (%s)", format.Help()))
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s lint [flags] [file...]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	logger := newLogger()
+
+	cfg := lint.Config{}
+	if config.String() != "" {
+		b, err := os.ReadFile(config.String())
+		if err != nil {
+			logger.Fatal().Err(err).Msg("unable to read --config")
+			os.Exit(1)
+		}
+		if cfg, err = lint.ParseConfig(b); err != nil {
+			logger.Fatal().Err(err).Msg("unable to load --config")
+			os.Exit(1)
+		}
+	}
+
+	linter, err := lint.New(cfg)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid --config")
+		os.Exit(1)
+	}
+
+	// Stdin is read when no files are provided, and its findings have no file.
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{""}
+	}
+
+	findings := []lint.Finding{}
+	for _, file := range files {
+		var names []string
+		if file != "" {
+			names = []string{file}
+		}
+
+		docs, err := readDocuments(names)
+		if err != nil {
+			logger.Fatal().Err(err).Str("file", file).Msg("unable to read input")
+			os.Exit(1)
+		}
+
+		for n, doc := range docs {
+			findings = append(findings, linter.Lint(file, n, doc)...)
+		}
+	}
+
+	switch format.String() {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(struct {
+			Findings []lint.Finding `json:"findings"`
+		}{findings})
+	case "github":
+		for _, f := range findings {
+			fmt.Fprintf(os.Stdout, "::%s file=%s,title=%s::%s\n", f.Severity, escapeProperty(f.File), f.Rule, escapeData(annotationMessage(f)))
+		}
+	}
+
+	for _, f := range findings {
+		if f.Severity == lint.SeverityError {
+			os.Exit(1)
+		}
+	}
+}
+
+// annotationMessage prefixes the finding's message with the Lockbox it applies to.
+func annotationMessage(f lint.Finding) string {
+	if f.Lockbox == "" {
+		return f.Message
+	}
+	return f.Lockbox + ": " + f.Message
+}
+
+// escapeData escapes characters with special meaning in a workflow command's message.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes characters with special meaning in a workflow command's property.
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -1,17 +1,28 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/mlkem"
 	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
 	"os"
 	gruntime "runtime"
+	"strings"
 	"time"
 
 	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
 	"github.com/cloudflare/lockbox/pkg/flagvar"
+	"github.com/cloudflare/lockbox/pkg/keyring"
 	"github.com/go-logr/zerologr"
 	"github.com/kevinburke/nacl"
 	"github.com/kevinburke/nacl/box"
@@ -35,19 +46,45 @@ var (
 	peerHex      string
 	masterURL    string
 	lockboxNS    string
+	secretNS     string
 	lockboxSvc   string
+	escrowKeys   = flagvar.Strings{}
+	legacy       bool
+	pq           bool
+	ageFormat    bool
+	ageRecipient bool
+	fingerprint  bool
+	noValidate   bool
+	keyURL       string
+	caFile       = flagvar.File{}
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import":
+			importMain(os.Args[2:])
+			return
+		case "verify":
+			verifyMain(os.Args[2:])
+			return
+		case "lint":
+			lintMain(os.Args[2:])
+			return
+		}
+	}
+
 	flag.Var(&input, "f", fmt.Sprintf("input file (%s)", input.Help()))
-	flag.Var(&output, "o", fmt.Sprintf("output format (%s)", output.Help()))
-	flag.Var(&kubeconfig, "kubeconfig", fmt.Sprintf("path to kubeconfig. (%s)", kubeconfig.Help()))
-	flag.StringVar(&peerHex, "peer-hex", "", "peer public key (32-bit hex)")
-	flag.StringVar(&masterURL, "master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
-	flag.StringVar(&lockboxNS, "lockbox-namespace", "lockbox", "namespace of the lockbox controller")
-	flag.StringVar(&lockboxSvc, "lockbox-service", "lockbox", "name of the lockbox service")
+	addCommonFlags(flag.CommandLine)
+	flag.StringVar(&secretNS, "namespace", "", "namespace to lock the secret for when it doesn't set one, instead of the kubeconfig's")
+	flag.Var(&escrowKeys, "escrow-key", fmt.Sprintf("additional recipient public keys (32-bit hex) able to unlock the Lockbox offline (%s)", escrowKeys.Help()))
+	flag.BoolVar(&legacy, "legacy", false, "lock in the legacy format, encrypting each value for the peer key, for controllers that don't support envelope encryption")
+	flag.BoolVar(&pq, "pq", false, "lock with the hybrid Curve25519 and ML-KEM-768 algorithm, requiring both to unlock. --peer-hex must be the hybrid public key")
+	flag.BoolVar(&ageFormat, "age", false, "lock each value as an age file for the peer key and any --escrow-key, readable with standard age tooling")
+	flag.BoolVar(&ageRecipient, "age-recipient", false, "print the peer key as an age recipient string and exit")
+	flag.BoolVar(&fingerprint, "fingerprint", false, "print the fingerprint of the peer key for the namespace, as listed in locket lint environments, and exit")
+	flag.BoolVar(&noValidate, "no-validate", false, "lock the secret without checking its data is valid for its type")
 	flag.BoolVar(&printVersion, "version", false, "print version")
-	flag.String("v", "", "log level for V logs")
 	flag.Parse()
 
 	ctx := context.Background()
@@ -57,13 +94,7 @@ func main() {
 		os.Exit(0)
 	}
 
-	zerolog.TimeFieldFormat = zerolog.TimeFormatUnixMs
-	zerologr.NameFieldName = "logger"
-	zerologr.NameSeparator = "/"
-
-	zl := zerolog.New(os.Stderr).With().Caller().Timestamp().Logger()
-	logf.SetLogger(zerologr.New(&zl))
-	logger := zl.With().Str("name", "main").Logger()
+	logger := newLogger()
 
 	err := lockboxv1.AddToScheme(scheme.Scheme)
 	if err != nil {
@@ -71,32 +102,41 @@ func main() {
 		os.Exit(1)
 	}
 
-	var r io.Reader
-	if input.String() == "" {
-		r = os.Stdin
-	} else {
-		r, err = os.Open(input.String())
-		if err != nil {
-			logger.Fatal().Err(err).Msg("unable to open secret file")
-			os.Exit(1)
-		}
-	}
-
 	w := os.Stdout
 
 	cfg := GetConfig()
 
 	cf := runtimeserializer.NewCodecFactory(scheme.Scheme)
 
-	ib, err := io.ReadAll(r)
-	if err != nil {
-		logger.Fatal().Err(err).Msg("unable to read secret file")
-		os.Exit(1)
-	}
 	var secret corev1.Secret
-	if err = runtime.DecodeInto(cf.UniversalDecoder(), ib, &secret); err != nil {
-		logger.Fatal().Err(err).Msg("unable to decode secret file")
-		os.Exit(1)
+	if !ageRecipient && !fingerprint {
+		var r io.Reader
+		if input.String() == "" {
+			r = os.Stdin
+		} else {
+			r, err = os.Open(input.String())
+			if err != nil {
+				logger.Fatal().Err(err).Msg("unable to open secret file")
+				os.Exit(1)
+			}
+		}
+
+		ib, err := io.ReadAll(r)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("unable to read secret file")
+			os.Exit(1)
+		}
+		if err = runtime.DecodeInto(cf.UniversalDecoder(), ib, &secret); err != nil {
+			logger.Fatal().Err(err).Msg("unable to decode secret file")
+			os.Exit(1)
+		}
+
+		if !noValidate {
+			if err := lockboxv1.ValidateSecret(&secret); err != nil {
+				logger.Fatal().Err(err).Msg("invalid secret data, use --no-validate to lock anyway")
+				os.Exit(1)
+			}
+		}
 	}
 
 	pubKey, priKey, err := box.GenerateKey(rand.Reader)
@@ -105,39 +145,62 @@ func main() {
 		os.Exit(1)
 	}
 
-	var peerKey nacl.Key
-	switch peerHex {
-	case "":
-		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
-		defer cancel()
+	namespace := secret.Namespace
+	if namespace == "" {
+		namespace, _, _ = cfg.Namespace()
+	}
+
+	if legacy && len(escrowKeys.Value) > 0 {
+		logger.Fatal().Msg("--escrow-key can't be used with --legacy")
+		os.Exit(1)
+	}
+	if pq && (legacy || len(escrowKeys.Value) > 0) {
+		logger.Fatal().Msg("--pq can't be used with --legacy or --escrow-key")
+		os.Exit(1)
+	}
+	if ageFormat && (legacy || pq) {
+		logger.Fatal().Msg("--age can't be used with --legacy or --pq")
+		os.Exit(1)
+	}
 
-		cc, err := cfg.ClientConfig()
+	algorithm := lockboxv1.AlgorithmNaClBox
+	if pq {
+		algorithm = lockboxv1.AlgorithmX25519MLKEM768
+	}
+
+	var peerKey nacl.Key
+	var peerKEM *mlkem.EncapsulationKey768
+	switch {
+	case pq && peerHex != "":
+		b, err := hex.DecodeString(peerHex)
 		if err != nil {
-			logger.Fatal().Err(err).Msg("unable to create API client configuration")
+			logger.Fatal().Err(err).Msg("could not decode --peer-hex")
 			os.Exit(1)
 		}
 
-		cc.UserAgent = fmt.Sprintf("%s/%s (%s/%s)", os.Args[0], version, gruntime.GOOS, gruntime.GOARCH)
-
-		client, err := kubernetes.NewForConfig(cc)
+		peerKey, peerKEM, err = lockboxv1.ParseHybridPublicKey(b)
 		if err != nil {
-			logger.Fatal().Err(err).Msg("unable to create API client")
+			logger.Fatal().Err(err).Msg("could not load --peer-hex")
 			os.Exit(1)
 		}
-
-		b, err := GetRemotePublicKey(ctx, client, lockboxNS, lockboxSvc)
+	case peerHex == "" && pq:
+		b, err := fetchPublicKey(ctx, cfg, namespace, algorithm)
 		if err != nil {
 			logger.Fatal().Err(err).Msg("unable to fetch public key")
 			os.Exit(1)
 		}
-		if len(b) != 32 {
-			err = fmt.Errorf("incorrect peer key length: %d, should be 32", len(b))
+
+		peerKey, peerKEM, err = lockboxv1.ParseHybridPublicKey(b)
+		if err != nil {
 			logger.Fatal().Err(err).Msg("unable to fetch peer key")
-			return
+			os.Exit(1)
+		}
+	case peerHex == "":
+		peerKey, err = fetchPeerKey(ctx, cfg, namespace)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("unable to fetch peer key")
+			os.Exit(1)
 		}
-
-		peerKey = new([nacl.KeySize]byte)
-		copy(peerKey[:], b)
 	default:
 		peerKey, err = nacl.Load(peerHex)
 		if err != nil {
@@ -146,13 +209,124 @@ func main() {
 		}
 	}
 
-	namespace := secret.Namespace
-	if namespace == "" {
-		namespace, _, _ = cfg.Namespace()
+	if ageRecipient {
+		fmt.Fprintln(w, lockboxv1.AgeRecipient(peerKey))
+		return
+	}
+
+	if fingerprint {
+		fmt.Fprintln(w, keyring.Fingerprint(peerKey))
+		return
+	}
+
+	escrow := make([]nacl.Key, 0, len(escrowKeys.Value))
+	for _, escrowHex := range escrowKeys.Value {
+		escrowKey, err := nacl.Load(escrowHex)
+		if err != nil {
+			logger.Fatal().Err(err).Str("key", escrowHex).Msg("could not load --escrow-key")
+			os.Exit(1)
+		}
+		escrow = append(escrow, escrowKey)
+	}
+
+	var b *lockboxv1.Lockbox
+	switch {
+	case ageFormat:
+		b, err = lockboxv1.NewAgeFromSecret(secret, namespace, peerKey, escrow...)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("unable to lock secret with age")
+			os.Exit(1)
+		}
+	case pq:
+		b = lockboxv1.NewHybridFromSecret(secret, namespace, peerKey, peerKEM, pubKey, priKey)
+	case legacy:
+		b = lockboxv1.NewLegacyFromSecret(secret, namespace, peerKey, pubKey, priKey)
+	case len(escrow) == 0:
+		b = lockboxv1.NewFromSecret(secret, namespace, peerKey, pubKey, priKey)
+	default:
+		b = lockboxv1.NewFromSecretWithRecipients(secret, namespace, append([]nacl.Key{peerKey}, escrow...), pubKey, priKey)
+	}
+
+	if err := writeLockbox(w, cf, b); err != nil {
+		logger.Fatal().Err(err).Msg("unable to encode Lockbox")
+		os.Exit(1)
+	}
+}
+
+// addCommonFlags registers the flags shared by all commands, for connecting to the
+// controller and formatting Lockboxes.
+func addCommonFlags(fs *flag.FlagSet) {
+	fs.Var(&output, "o", fmt.Sprintf("output format (%s)", output.Help()))
+	fs.Var(&kubeconfig, "kubeconfig", fmt.Sprintf("path to kubeconfig. (%s)", kubeconfig.Help()))
+	fs.StringVar(&peerHex, "peer-hex", "", "peer public key (32-bit hex)")
+	fs.StringVar(&masterURL, "master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
+	fs.StringVar(&lockboxNS, "lockbox-namespace", "lockbox", "namespace of the lockbox controller")
+	fs.StringVar(&lockboxSvc, "lockbox-service", "lockbox", "name of the lockbox service")
+	fs.StringVar(&keyURL, "key-url", "", "https URL of the lockbox controller's HTTP server, to fetch public keys from directly rather than through the Kubernetes API")
+	fs.Var(&caFile, "ca-file", fmt.Sprintf("CA certificates to trust for --key-url, replacing the system roots (%s)", caFile.Help()))
+	fs.String("v", "", "log level for V logs")
+}
+
+func newLogger() zerolog.Logger {
+	zerolog.TimeFieldFormat = zerolog.TimeFormatUnixMs
+	zerologr.NameFieldName = "logger"
+	zerologr.NameSeparator = "/"
+
+	zl := zerolog.New(os.Stderr).With().Caller().Timestamp().Logger()
+	logf.SetLogger(zerologr.New(&zl))
+	return zl.With().Str("name", "main").Logger()
+}
+
+// fetchPublicKey fetches the controller's public key for the algorithm and namespace.
+func fetchPublicKey(ctx context.Context, cfg clientcmd.ClientConfig, namespace, algorithm string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if keyURL != "" {
+		return GetURLPublicKey(ctx, keyURL, caFile.Value, namespace, algorithm)
+	}
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetRemotePublicKey(ctx, client, lockboxNS, lockboxSvc, namespace, algorithm)
+}
+
+// newClient creates a Kubernetes API client from the configuration.
+func newClient(cfg clientcmd.ClientConfig) (kubernetes.Interface, error) {
+	cc, err := cfg.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create API client configuration: %w", err)
+	}
+
+	cc.UserAgent = fmt.Sprintf("%s/%s (%s/%s)", os.Args[0], version, gruntime.GOOS, gruntime.GOARCH)
+
+	client, err := kubernetes.NewForConfig(cc)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create API client: %w", err)
+	}
+	return client, nil
+}
+
+// fetchPeerKey fetches the controller's Curve25519 public key for the namespace.
+func fetchPeerKey(ctx context.Context, cfg clientcmd.ClientConfig, namespace string) (nacl.Key, error) {
+	b, err := fetchPublicKey(ctx, cfg, namespace, lockboxv1.AlgorithmNaClBox)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != nacl.KeySize {
+		return nil, fmt.Errorf("incorrect peer key length: %d, should be %d", len(b), nacl.KeySize)
 	}
 
-	b := lockboxv1.NewFromSecret(secret, namespace, peerKey, pubKey, priKey)
+	peerKey := new([nacl.KeySize]byte)
+	copy(peerKey[:], b)
+	return peerKey, nil
+}
 
+// writeLockbox encodes the Lockbox in the selected output format.
+func writeLockbox(w io.Writer, cf runtimeserializer.CodecFactory, lb *lockboxv1.Lockbox) error {
 	var ct string
 	switch output.String() {
 	case "yaml":
@@ -163,8 +337,7 @@ func main() {
 
 	info, ok := runtime.SerializerInfoForMediaType(cf.SupportedMediaTypes(), ct)
 	if !ok {
-		logger.Fatal().Str("content-type", ct).Msg("can't serialize to content-type")
-		os.Exit(1)
+		return fmt.Errorf("can't serialize to content-type %s", ct)
 	}
 	serial := info.Serializer
 	if info.PrettySerializer != nil {
@@ -172,18 +345,16 @@ func main() {
 	}
 	enc := cf.EncoderForVersion(serial, lockboxv1.GroupVersion)
 
-	ob, err := runtime.Encode(enc, b)
+	ob, err := runtime.Encode(enc, lb)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("unable to encode Lockbox")
-		os.Exit(1)
+		return err
 	}
 
 	if _, err := w.Write(ob); err != nil {
-		logger.Fatal().Err(err).Send()
-	}
-	if _, err := w.WriteString("\n"); err != nil {
-		logger.Fatal().Err(err).Send()
+		return err
 	}
+	_, err = io.WriteString(w, "\n")
+	return err
 }
 
 func GetConfig() clientcmd.ClientConfig {
@@ -192,11 +363,162 @@ func GetConfig() clientcmd.ClientConfig {
 		ClusterInfo: clientcmdapi.Cluster{
 			Server: masterURL,
 		},
+		Context: clientcmdapi.Context{
+			Namespace: secretNS,
+		},
 	}
 	loader.ExplicitPath = kubeconfig.String()
 	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loader, &overrides)
 }
 
-func GetRemotePublicKey(ctx context.Context, c kubernetes.Interface, ns, svc string) ([]byte, error) {
-	return c.CoreV1().Services(ns).ProxyGet("http", svc, "", "/v1/public", nil).DoRaw(ctx)
+// GetRemotePublicKey fetches the public key for the algorithm. The active LockboxKey
+// published by the controller for the Lockbox's namespace is preferred, as reading it
+// doesn't require access to the controller's service, followed by the active keypair's
+// LockboxKey unless the controller derives namespace keys from it. Otherwise, the key
+// derived for the Lockbox's namespace is fetched from the Lockbox controller's service.
+func GetRemotePublicKey(ctx context.Context, c kubernetes.Interface, ns, svc, lockboxNamespace, algorithm string) ([]byte, error) {
+	key, err := getLockboxKey(ctx, c, lockboxNamespace, algorithm)
+	if err == nil && key != nil {
+		if algorithm == lockboxv1.AlgorithmX25519MLKEM768 {
+			return key.HybridPublicKey, nil
+		}
+		return key.PublicKey, nil
+	}
+
+	params := map[string]string{"namespace": lockboxNamespace}
+	if algorithm != lockboxv1.AlgorithmNaClBox {
+		params["algorithm"] = algorithm
+	}
+	return c.CoreV1().Services(ns).ProxyGet("http", svc, "", "/v1/public", params).DoRaw(ctx)
+}
+
+// getLockboxKey returns the spec of the active LockboxKey to lock Lockboxes in the
+// namespace for, or nil if none is published with a key for the algorithm.
+func getLockboxKey(ctx context.Context, c kubernetes.Interface, namespace, algorithm string) (*lockboxv1.LockboxKeySpec, error) {
+	if namespace != "" {
+		key, err := listActiveLockboxKey(ctx, c, lockboxv1.LockboxKeyNamespaceLabel+"="+namespace)
+		if err != nil || key != nil {
+			return usableLockboxKey(key, algorithm), err
+		}
+	}
+
+	key, err := listActiveLockboxKey(ctx, c, "!"+lockboxv1.LockboxKeyNamespaceLabel)
+	if err != nil || key == nil || (namespace != "" && key.NamespaceKeys) {
+		return nil, err
+	}
+	return usableLockboxKey(key, algorithm), nil
+}
+
+// listActiveLockboxKey returns the spec of the active LockboxKey matching the label
+// selector, if any.
+func listActiveLockboxKey(ctx context.Context, c kubernetes.Interface, selector string) (*lockboxv1.LockboxKeySpec, error) {
+	b, err := c.Discovery().RESTClient().Get().
+		AbsPath("/apis", lockboxv1.GroupVersion.Group, lockboxv1.GroupVersion.Version, "lockboxkeys").
+		Param("labelSelector", selector).
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := &lockboxv1.LockboxKeyList{}
+	if err := json.Unmarshal(b, keys); err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys.Items {
+		if key.Spec.Active {
+			return &key.Spec, nil
+		}
+	}
+	return nil, nil
+}
+
+// usableLockboxKey returns the key if it has a public key for the algorithm, or nil.
+func usableLockboxKey(key *lockboxv1.LockboxKeySpec, algorithm string) *lockboxv1.LockboxKeySpec {
+	if key == nil || (algorithm == lockboxv1.AlgorithmX25519MLKEM768 && len(key.HybridPublicKey) == 0) {
+		return nil
+	}
+	return key
+}
+
+// GetURLPublicKey fetches the public key for the algorithm derived for the Lockbox's
+// namespace from the Lockbox controller's HTTP server at the base URL. If caFile is set,
+// only server certificates issued by its CAs are trusted.
+func GetURLPublicKey(ctx context.Context, baseURL, caFile, lockboxNamespace, algorithm string) ([]byte, error) {
+	params := url.Values{}
+	if lockboxNamespace != "" {
+		params.Set("namespace", lockboxNamespace)
+	}
+	if algorithm != lockboxv1.AlgorithmNaClBox {
+		params.Set("algorithm", algorithm)
+	}
+
+	return doURL(ctx, baseURL, caFile, http.MethodGet, "/v1/public", params, nil)
+}
+
+// doURL makes a request to the path on the Lockbox controller's HTTP server at the base
+// URL, returning the response body. Only https URLs are accepted, as the response is the
+// key secrets are locked for. If caFile is set, only server certificates issued by its CAs
+// are trusted, replacing the system roots.
+func doURL(ctx context.Context, baseURL, caFile, method, path string, params url.Values, body []byte) ([]byte, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key URL: %w", err)
+	}
+	if u.Scheme != "https" {
+		return nil, fmt.Errorf("key URL must use https, not %q", u.Scheme)
+	}
+	u = u.JoinPath(path)
+	u.RawQuery = params.Encode()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:    roots,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", fmt.Sprintf("%s/%s (%s/%s)", os.Args[0], version, gruntime.GOOS, gruntime.GOARCH))
+
+	c := &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "https" {
+				return fmt.Errorf("refusing redirect to %s URL", req.URL.Scheme)
+			}
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return nil
+		},
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response %s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+
+	return b, nil
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestGetRemotePublicKey(t *testing.T) {
+	master := lockboxv1.LockboxKey{
+		Spec: lockboxv1.LockboxKeySpec{PublicKey: []byte("master"), NamespaceKeys: true, Active: true},
+	}
+	example := lockboxv1.LockboxKey{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{lockboxv1.LockboxKeyNamespaceLabel: "example"}},
+		Spec:       lockboxv1.LockboxKeySpec{PublicKey: []byte("example"), Namespace: "example", Active: true},
+	}
+
+	// The API server publishes LockboxKeys, but the caller can't use the service proxy.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/apis/lockbox.k8s.cloudflare.com/v1/lockboxkeys", func(w http.ResponseWriter, r *http.Request) {
+		selector, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+		assert.NilError(t, err)
+
+		list := lockboxv1.LockboxKeyList{}
+		for _, key := range []lockboxv1.LockboxKey{master, example} {
+			if selector.Matches(labels.Set(key.Labels)) {
+				list.Items = append(list.Items, key)
+			}
+		}
+		assert.NilError(t, json.NewEncoder(w).Encode(list))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c, err := kubernetes.NewForConfig(&rest.Config{Host: srv.URL})
+	assert.NilError(t, err)
+
+	type testCase struct {
+		name        string
+		namespace   string
+		expected    string
+		expectedErr string
+	}
+
+	run := func(t *testing.T, tc testCase) {
+		key, err := GetRemotePublicKey(context.Background(), c, "lockbox", "lockbox", tc.namespace, lockboxv1.AlgorithmNaClBox)
+		if tc.expectedErr != "" {
+			assert.ErrorContains(t, err, tc.expectedErr)
+			return
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, string(key), tc.expected)
+	}
+
+	testCases := []testCase{
+		{
+			name:      "namespace key",
+			namespace: "example",
+			expected:  "example",
+		},
+		{
+			name:     "master key",
+			expected: "master",
+		},
+		{
+			// Namespaces created since keys were published fall back to the service proxy.
+			name:        "unpublished namespace",
+			namespace:   "other",
+			expectedErr: "get services http:lockbox:",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			run(t, tc)
+		})
+	}
+}
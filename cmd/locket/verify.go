@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	controller "github.com/cloudflare/lockbox/pkg/lockbox-controller"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+)
+
+// verifyMain implements "locket verify", asking the controller whether Lockboxes can be
+// unlocked. It exits with a non-zero status if any can't.
+func verifyMain(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	addCommonFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s verify [flags] [file...]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	logger := newLogger()
+
+	docs, err := readDocuments(fs.Args())
+	if err != nil {
+		logger.Fatal().Err(err).Msg("unable to read input")
+		os.Exit(1)
+	}
+
+	cfg := GetConfig()
+	valid := true
+	for n, doc := range docs {
+		v, err := verifyLockbox(context.Background(), cfg, doc)
+		if err != nil {
+			logger.Fatal().Err(err).Int("document", n).Msg("unable to verify Lockbox")
+			os.Exit(1)
+		}
+		valid = valid && v.Valid
+
+		var b []byte
+		switch output.String() {
+		case "yaml":
+			if n > 0 {
+				fmt.Fprintln(os.Stdout, "---")
+			}
+			b, err = yaml.Marshal(v)
+		case "json":
+			b, err = json.MarshalIndent(v, "", "  ")
+			b = append(b, '\n')
+		}
+		if err != nil {
+			logger.Fatal().Err(err).Msg("unable to encode verification")
+			os.Exit(1)
+		}
+		_, _ = os.Stdout.Write(b)
+	}
+
+	if !valid {
+		os.Exit(1)
+	}
+}
+
+// verifyLockbox sends the Lockbox manifest to the controller's verify endpoint, through
+// --key-url if set or the controller's service otherwise.
+func verifyLockbox(ctx context.Context, cfg clientcmd.ClientConfig, doc []byte) (*controller.Verification, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	body, err := yaml.YAMLToJSON(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var b []byte
+	if keyURL != "" {
+		b, err = doURL(ctx, keyURL, caFile.Value, http.MethodPost, "/v1/verify", nil, body)
+	} else {
+		var client kubernetes.Interface
+		client, err = newClient(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		b, err = client.CoreV1().RESTClient().Post().
+			Namespace(lockboxNS).
+			Resource("services").
+			Name(utilnet.JoinSchemeNamePort("http", lockboxSvc, "")).
+			SubResource("proxy").
+			Suffix("v1", "verify").
+			SetHeader("Content-Type", "application/json").
+			Body(body).
+			DoRaw(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	v := &controller.Verification{}
+	if err := json.Unmarshal(b, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
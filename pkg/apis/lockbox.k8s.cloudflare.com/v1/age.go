@@ -0,0 +1,223 @@
+package v1
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/kevinburke/nacl"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// AlgorithmAge stores the namespace and each value as an age file encrypted to the
+// Peer's X25519 age recipient, so they can be inspected with standard age tooling.
+const AlgorithmAge = "age"
+
+// ageX25519Label is the HKDF info used by age's X25519 recipient stanzas.
+const ageX25519Label = "age-encryption.org/v1/X25519"
+
+func init() {
+	RegisterDecryptor(AlgorithmAge, ageDecryptor{})
+}
+
+// AgeRecipient returns the age X25519 recipient string (age1...) for a public key.
+func AgeRecipient(pub nacl.Key) string {
+	return bech32Encode("age", pub[:])
+}
+
+// AgeIdentity returns the age X25519 identity string (AGE-SECRET-KEY-1...) for a
+// private key, for use with standard age tooling.
+func AgeIdentity(pri nacl.Key) string {
+	return strings.ToUpper(bech32Encode("age-secret-key-", pri[:]))
+}
+
+// NewAgeFromSecret creates a Lockbox with the AlgorithmAge algorithm, encrypting the
+// namespace and each value to the peer's age recipient. Any escrow public keys are added
+// as further recipients of each value, but aren't recorded in the Lockbox.
+func NewAgeFromSecret(secret corev1.Secret, namespace string, peer nacl.Key, escrow ...nacl.Key) (*Lockbox, error) {
+	var recipients []age.Recipient
+	for _, pub := range append([]nacl.Key{peer}, escrow...) {
+		r, err := age.ParseX25519Recipient(AgeRecipient(pub))
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, r)
+	}
+
+	var sealErr error
+	b := newFromSecret(secret, namespace, peer, nil, func(value []byte) []byte {
+		buf := &bytes.Buffer{}
+		w, err := age.Encrypt(buf, recipients...)
+		if err == nil {
+			_, err = w.Write(value)
+		}
+		if err == nil {
+			err = w.Close()
+		}
+		if err != nil && sealErr == nil {
+			sealErr = err
+		}
+		return buf.Bytes()
+	})
+	if sealErr != nil {
+		return nil, sealErr
+	}
+	b.Spec.Algorithm = AlgorithmAge
+
+	return b, nil
+}
+
+// ageDecryptor implements Decryptor for AlgorithmAge.
+type ageDecryptor struct{}
+
+// Opener implements Decryptor
+func (ageDecryptor) Opener(lb *Lockbox, peer nacl.Key, key KeyAgreement) (Opener, error) {
+	x, ok := key.(X25519KeyAgreement)
+	if !ok {
+		return nil, keyAgreementError{error: errors.New("key provider doesn't support age")}
+	}
+
+	return ageOpener{identity: ageIdentity{public: peer, key: x}}, nil
+}
+
+// ageOpener implements Opener.
+type ageOpener struct {
+	identity age.Identity
+}
+
+// Open implements Opener
+func (o ageOpener) Open(sealed []byte) ([]byte, error) {
+	r, err := age.Decrypt(bytes.NewReader(sealed), o.identity)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(r)
+}
+
+// ageIdentity implements age.Identity for X25519 recipient stanzas, using a private key
+// that may be held by a key provider.
+type ageIdentity struct {
+	public nacl.Key
+	key    X25519KeyAgreement
+}
+
+// Unwrap implements age.Identity
+func (i ageIdentity) Unwrap(stanzas []*age.Stanza) ([]byte, error) {
+	for _, s := range stanzas {
+		fileKey, err := i.unwrap(s)
+		if errors.Is(err, age.ErrIncorrectIdentity) {
+			continue
+		}
+		return fileKey, err
+	}
+
+	return nil, age.ErrIncorrectIdentity
+}
+
+func (i ageIdentity) unwrap(s *age.Stanza) ([]byte, error) {
+	if s.Type != "X25519" {
+		return nil, age.ErrIncorrectIdentity
+	}
+	if len(s.Args) != 1 {
+		return nil, errors.New("invalid X25519 recipient block")
+	}
+	share, err := base64.RawStdEncoding.Strict().DecodeString(s.Args[0])
+	if err != nil || len(share) != keySize {
+		return nil, errors.New("invalid X25519 recipient block")
+	}
+
+	shareKey := new([keySize]byte)
+	copy(shareKey[:], share)
+	sharedSecret, err := i.key.X25519(shareKey)
+	if err != nil {
+		return nil, keyAgreementError{error: err}
+	}
+
+	salt := append(append([]byte{}, share...), i.public[:]...)
+	wrappingKey := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, sharedSecret, salt, []byte(ageX25519Label)), wrappingKey); err != nil {
+		return nil, err
+	}
+
+	aead, err := chacha20poly1305.New(wrappingKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.Body) != 16+aead.Overhead() {
+		return nil, errors.New("invalid X25519 recipient block: incorrect file key size")
+	}
+
+	fileKey, err := aead.Open(nil, make([]byte, aead.NonceSize()), s.Body, nil)
+	if err != nil {
+		return nil, age.ErrIncorrectIdentity
+	}
+	return fileKey, nil
+}
+
+// bech32Encode encodes data as a BIP 173 Bech32 string with the human readable part,
+// without BIP 173's length limit, as age does.
+func bech32Encode(hrp string, data []byte) string {
+	const charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+	// Regroup the data from 8 bit to 5 bit values.
+	var values []byte
+	var acc, bits uint
+	for _, b := range data {
+		acc = acc<<8 | uint(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			values = append(values, byte(acc>>bits&31))
+		}
+	}
+	if bits > 0 {
+		values = append(values, byte(acc<<(5-bits)&31))
+	}
+
+	// Compute the checksum over the expanded human readable part and the values.
+	var expanded []byte
+	for _, c := range []byte(hrp) {
+		expanded = append(expanded, c>>5)
+	}
+	expanded = append(expanded, 0)
+	for _, c := range []byte(hrp) {
+		expanded = append(expanded, c&31)
+	}
+	expanded = append(append(expanded, values...), 0, 0, 0, 0, 0, 0)
+
+	checksum := bech32Polymod(expanded) ^ 1
+	for i := 0; i < 6; i++ {
+		values = append(values, byte(checksum>>(5*(5-i))&31))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, v := range values {
+		sb.WriteByte(charset[v])
+	}
+	return sb.String()
+}
+
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
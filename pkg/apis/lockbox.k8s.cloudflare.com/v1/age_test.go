@@ -0,0 +1,96 @@
+package v1_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"filippo.io/age"
+	v1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"github.com/kevinburke/nacl"
+	"github.com/kevinburke/nacl/box"
+	"github.com/kevinburke/nacl/scalarmult"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestAgeKeys(t *testing.T) {
+	pubKey, priKey, err := loadKeypair(t, "6a42b9fc2b011fb88c01741483e3bffe455bdab1ae35d0bb53a3c00d406d8836", "252173f975f0a0ddb198a7e5958c074203a0e9f44275e0b840f95d456c4acc2e")
+	assert.NilError(t, err)
+
+	identity, err := age.ParseX25519Identity(v1.AgeIdentity(priKey))
+	assert.NilError(t, err)
+	assert.Equal(t, identity.Recipient().String(), v1.AgeRecipient(pubKey))
+}
+
+func TestAgeKeysKnownAnswer(t *testing.T) {
+	type testCase struct {
+		private   string
+		identity  string
+		recipient string
+	}
+
+	run := func(t *testing.T, tc testCase) {
+		priKey, err := nacl.Load(tc.private)
+		assert.NilError(t, err)
+
+		assert.Equal(t, v1.AgeIdentity(priKey), tc.identity)
+		assert.Equal(t, v1.AgeRecipient(scalarmult.Base(priKey)), tc.recipient)
+	}
+
+	// Keys from age's own test data.
+	testCases := map[string]testCase{
+		"cmd testdata": {
+			private:   "ca1626252c53cf1a4dc46695e7fcc46594cb4ccadedb6712dfda0753f3ae355e",
+			identity:  "AGE-SECRET-KEY-1EGTZVFFV20835NWYV6270LXYVK2VKNX2MMDKWYKLMGR48UAWX40Q2P2LM0",
+			recipient: "age1xmwwc06ly3ee5rytxm9mflaz2u56jjj36s0mypdrwsvlul66mv4q47ryef",
+		},
+		"armor test": {
+			private:   "3d65b16d80bc73ae3c30f9f457bea48f153233c38bf3c29710cb661bbd44e313",
+			identity:  "AGE-SECRET-KEY-184JMZMVQH3E6U0PSL869004Y3U2NYV7R30EU99CSEDNPH02YUVFSZW44VU",
+			recipient: "age1cy0su9fwf3gf9mw868g5yut09p6nytfmmnktexz2ya5uqg9vl9sss4euqm",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			run(t, tc)
+		})
+	}
+}
+
+func TestLockUnlockAge(t *testing.T) {
+	serverPubKey, serverPriKey, _ := box.GenerateKey(rand.Reader)
+	escrowPubKey, escrowPriKey, _ := box.GenerateKey(rand.Reader)
+
+	secret := corev1.Secret{
+		Data: map[string][]byte{
+			"test": []byte("test"),
+		},
+	}
+
+	lb, err := v1.NewAgeFromSecret(secret, "namespace", serverPubKey, escrowPubKey)
+	assert.NilError(t, err)
+	assert.Equal(t, lb.Spec.Algorithm, v1.AlgorithmAge)
+	assert.Assert(t, lb.Spec.Sender == nil)
+
+	unlockedSecret := &corev1.Secret{}
+	assert.NilError(t, lb.UnlockInto(unlockedSecret, serverPriKey))
+	assert.DeepEqual(t, unlockedSecret.Data, secret.Data)
+
+	// Values can be decrypted with standard age tooling, by any recipient.
+	for _, pri := range []*[32]byte{serverPriKey, escrowPriKey} {
+		identity, err := age.ParseX25519Identity(v1.AgeIdentity(pri))
+		assert.NilError(t, err)
+
+		r, err := age.Decrypt(bytes.NewReader(lb.Spec.Data["test"]), identity)
+		assert.NilError(t, err)
+		value, err := io.ReadAll(r)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, value, []byte("test"))
+	}
+
+	_, otherPriKey, _ := box.GenerateKey(rand.Reader)
+	assert.ErrorContains(t, lb.UnlockInto(&corev1.Secret{}, otherPriKey), "no identity matched")
+}
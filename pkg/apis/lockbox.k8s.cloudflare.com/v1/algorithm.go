@@ -0,0 +1,196 @@
+package v1
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/kevinburke/nacl"
+	"github.com/kevinburke/nacl/secretbox"
+)
+
+// AlgorithmNaClBox is NaCl's box (Curve25519, XSalsa20, and Poly1305), used by Lockboxes
+// that don't specify an algorithm.
+const AlgorithmNaClBox = "nacl-box"
+
+// KeyAgreement performs key agreement with the private key of a Lockbox recipient, which
+// may be held outside of memory.
+// +kubebuilder:object:generate=false
+type KeyAgreement interface {
+	// SharedKey returns the NaCl box shared key between the private key and the peer's
+	// public key, as computed by box.Precompute.
+	SharedKey(peer nacl.Key) (nacl.Key, error)
+}
+
+// X25519KeyAgreement is a KeyAgreement that also provides the raw X25519 shared secret,
+// as required by algorithms that don't use NaCl box, such as age.
+// +kubebuilder:object:generate=false
+type X25519KeyAgreement interface {
+	KeyAgreement
+
+	// X25519 returns the X25519 shared secret between the private key and the peer's
+	// public key.
+	X25519(peer nacl.Key) ([]byte, error)
+}
+
+// Decryptor unlocks Lockboxes locked with a single cryptographic algorithm.
+// +kubebuilder:object:generate=false
+type Decryptor interface {
+	// Opener returns an Opener for the Lockbox's namespace and values, for the
+	// recipient with the peer public key.
+	Opener(lb *Lockbox, peer nacl.Key, key KeyAgreement) (Opener, error)
+}
+
+// Opener decrypts the namespace and secret values of a single Lockbox.
+// +kubebuilder:object:generate=false
+type Opener interface {
+	Open(sealed []byte) ([]byte, error)
+}
+
+// dataKeyDecryptor is implemented by Decryptors for algorithms encrypting the namespace
+// and values with a single data key, sealed for each recipient.
+// +kubebuilder:object:generate=false
+type dataKeyDecryptor interface {
+	DataKey(lb *Lockbox, peer nacl.Key, key KeyAgreement) (nacl.Key, error)
+}
+
+// DataKeyOpener returns an Opener for values sealed with NaCl secretbox using the data key.
+func DataKeyOpener(dataKey nacl.Key) Opener {
+	return dataKeyOpener{dataKey: dataKey}
+}
+
+// dataKeyOpener implements Opener.
+type dataKeyOpener struct {
+	dataKey nacl.Key
+}
+
+// Open implements Opener
+func (o dataKeyOpener) Open(sealed []byte) ([]byte, error) {
+	return secretbox.EasyOpen(sealed, o.dataKey)
+}
+
+var (
+	decryptorsMu sync.RWMutex
+	decryptors   = map[string]Decryptor{
+		AlgorithmNaClBox: naclBox{},
+	}
+)
+
+// RegisterDecryptor makes a Decryptor available for Lockboxes with the algorithm,
+// replacing any Decryptor already registered for it.
+func RegisterDecryptor(algorithm string, d Decryptor) {
+	decryptorsMu.Lock()
+	defer decryptorsMu.Unlock()
+
+	decryptors[algorithm] = d
+}
+
+// Decryptor returns the Decryptor for the Lockbox's algorithm.
+func (in *Lockbox) Decryptor() (Decryptor, error) {
+	algorithm := in.Spec.Algorithm
+	if algorithm == "" {
+		algorithm = AlgorithmNaClBox
+	}
+
+	decryptorsMu.RLock()
+	defer decryptorsMu.RUnlock()
+
+	d, ok := decryptors[algorithm]
+	if !ok {
+		return nil, unsupportedAlgorithmError{algorithm: algorithm}
+	}
+	return d, nil
+}
+
+// naclBox implements Decryptor for AlgorithmNaClBox, in both the legacy and envelope
+// formats.
+type naclBox struct{}
+
+// DataKey implements dataKeyDecryptor
+func (naclBox) DataKey(lb *Lockbox, peer nacl.Key, key KeyAgreement) (nacl.Key, error) {
+	sender := lb.SenderFor(peer)
+	if sender == nil {
+		return nil, invalidKeyLengthError{name: "sender"}
+	}
+
+	sharedKey, err := key.SharedKey(sender)
+	if err != nil {
+		return nil, keyAgreementError{error: err}
+	}
+
+	// Legacy Lockboxes encrypt values directly with the shared key.
+	if !lb.IsEnvelope() {
+		return sharedKey, nil
+	}
+
+	r := lb.recipient(peer)
+	if r == nil {
+		return nil, fmt.Errorf("lockbox has no recipient for peer key %x", peer[:])
+	}
+
+	dataKey, err := secretbox.EasyOpen(r.Key, sharedKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open data key: %w", err)
+	}
+	if len(dataKey) != keySize {
+		return nil, fmt.Errorf("incorrect data key length: %d, should be %d", len(dataKey), keySize)
+	}
+
+	k := new([keySize]byte)
+	copy(k[:], dataKey)
+	return k, nil
+}
+
+// Opener implements Decryptor
+func (d naclBox) Opener(lb *Lockbox, peer nacl.Key, key KeyAgreement) (Opener, error) {
+	dataKey, err := d.DataKey(lb, peer, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return DataKeyOpener(dataKey), nil
+}
+
+// unsupportedAlgorithmError is returned for Lockboxes locked with an algorithm
+// without a registered Decryptor.
+type unsupportedAlgorithmError struct {
+	algorithm string
+}
+
+func (e unsupportedAlgorithmError) Error() string {
+	return fmt.Sprintf("unsupported lockbox algorithm %q", e.algorithm)
+}
+
+// Algorithm returns the unsupported algorithm.
+func (e unsupportedAlgorithmError) Algorithm() string {
+	return e.algorithm
+}
+
+// invalidKeyLengthError is returned for Lockboxes with a public key of the wrong length.
+type invalidKeyLengthError struct {
+	name string
+}
+
+func (e invalidKeyLengthError) Error() string {
+	return fmt.Sprintf("incorrect %s key length, should be %d", e.name, keySize)
+}
+
+// InvalidKeyLength returns the name of the key with the wrong length.
+func (e invalidKeyLengthError) InvalidKeyLength() string {
+	return e.name
+}
+
+// keyAgreementError wraps errors returned by a KeyAgreement, as opposed to errors
+// caused by the Lockbox's contents.
+type keyAgreementError struct {
+	error
+}
+
+// KeyAgreement reports that this error was returned by a KeyAgreement.
+func (e keyAgreementError) KeyAgreement() bool {
+	return true
+}
+
+// Unwrap implements Wrapper, returning the underlying error message.
+func (e keyAgreementError) Unwrap() error {
+	return e.error
+}
@@ -0,0 +1,122 @@
+package v1_test
+
+import (
+	"crypto/rand"
+	"testing"
+
+	v1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"github.com/kevinburke/nacl"
+	"github.com/kevinburke/nacl/box"
+	"golang.org/x/crypto/curve25519"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// privateKey implements v1.X25519KeyAgreement for tests.
+type privateKey struct {
+	pri nacl.Key
+}
+
+func (p privateKey) SharedKey(peer nacl.Key) (nacl.Key, error) {
+	return box.Precompute(peer, p.pri), nil
+}
+
+func (p privateKey) X25519(peer nacl.Key) ([]byte, error) {
+	return curve25519.X25519(p.pri[:], peer[:])
+}
+
+// reversed is a toy Decryptor that stores values reversed, to exercise the registry.
+type reversed struct{}
+
+func (reversed) Opener(lb *v1.Lockbox, peer nacl.Key, key v1.KeyAgreement) (v1.Opener, error) {
+	return reversed{}, nil
+}
+
+func (reversed) Open(sealed []byte) ([]byte, error) {
+	out := make([]byte, len(sealed))
+	for i, b := range sealed {
+		out[len(sealed)-1-i] = b
+	}
+	return out, nil
+}
+
+func TestAlgorithm(t *testing.T) {
+	senderPubKey, senderPriKey, _ := box.GenerateKey(rand.Reader)
+	serverPubKey, serverPriKey, _ := box.GenerateKey(rand.Reader)
+
+	secret := corev1.Secret{
+		Data: map[string][]byte{
+			"test": []byte("test"),
+		},
+	}
+
+	type testCase struct {
+		algorithm string
+		wantErr   string
+	}
+
+	run := func(t *testing.T, tc testCase) {
+		lb := v1.NewFromSecret(secret, "namespace", serverPubKey, senderPubKey, senderPriKey)
+		assert.Equal(t, lb.Spec.Algorithm, v1.AlgorithmNaClBox)
+		lb.Spec.Algorithm = tc.algorithm
+
+		unlocked := &corev1.Secret{}
+		err := lb.UnlockInto(unlocked, serverPriKey)
+		if tc.wantErr != "" {
+			assert.ErrorContains(t, err, tc.wantErr)
+			return
+		}
+		assert.NilError(t, err)
+		assert.DeepEqual(t, unlocked.Data, secret.Data)
+	}
+
+	testCases := map[string]testCase{
+		"nacl-box": {
+			algorithm: v1.AlgorithmNaClBox,
+		},
+		"unset": {
+			algorithm: "",
+		},
+		"unsupported": {
+			algorithm: "rot13",
+			wantErr:   `unsupported lockbox algorithm "rot13"`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			run(t, tc)
+		})
+	}
+}
+
+func TestRegisterDecryptor(t *testing.T) {
+	v1.RegisterDecryptor("reversed", reversed{})
+
+	lb := &v1.Lockbox{
+		Spec: v1.LockboxSpec{
+			Algorithm: "reversed",
+			Namespace: []byte("ecapseman"),
+			Data: map[string][]byte{
+				"test": []byte("olleh"),
+			},
+		},
+	}
+
+	d, err := lb.Decryptor()
+	assert.NilError(t, err)
+
+	o, err := d.Opener(lb, nacl.NewKey(), privateKey{nacl.NewKey()})
+	assert.NilError(t, err)
+
+	namespace, err := lb.OpenNamespace(o)
+	assert.NilError(t, err)
+	assert.Equal(t, namespace, "namespace")
+
+	_, err = lb.DataKey(nacl.NewKey(), privateKey{nacl.NewKey()})
+	assert.ErrorContains(t, err, "reversed lockboxes have no data key")
+
+	data, err := lb.UnlockShared(o)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, data, map[string][]byte{"test": []byte("hello")})
+}
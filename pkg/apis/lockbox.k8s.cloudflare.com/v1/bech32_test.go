@@ -0,0 +1,46 @@
+package v1
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestBech32Encode(t *testing.T) {
+	type testCase struct {
+		hrp      string
+		data     string
+		expected string
+	}
+
+	run := func(t *testing.T, tc testCase) {
+		data, err := hex.DecodeString(tc.data)
+		assert.NilError(t, err)
+		assert.Equal(t, bech32Encode(tc.hrp, data), tc.expected)
+	}
+
+	// Valid Bech32 strings from BIP 173, with data that regroups into whole bytes.
+	testCases := map[string]testCase{
+		"empty data": {
+			hrp:      "a",
+			expected: "a12uel5l",
+		},
+		"every character": {
+			hrp:      "abcdef",
+			data:     "00443214c74254b635cf84653a56d7c675be77df",
+			expected: "abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+		},
+		"long data": {
+			hrp:      "split",
+			data:     "c5f38b70305f519bf66d85fb6cf03058f3dde463ecd7918f2dc743918f2d",
+			expected: "split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			run(t, tc)
+		})
+	}
+}
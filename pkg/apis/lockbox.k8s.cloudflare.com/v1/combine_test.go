@@ -0,0 +1,40 @@
+package v1
+
+import (
+	"bytes"
+	"crypto/mlkem"
+	"encoding/hex"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestCombineHybridKnownAnswer(t *testing.T) {
+	fill := func(b byte, n int) []byte {
+		return bytes.Repeat([]byte{b}, n)
+	}
+	key := func(b byte) *[keySize]byte {
+		k := new([keySize]byte)
+		copy(k[:], fill(b, keySize))
+		return k
+	}
+
+	sharedKey, sender, peer := key(0x01), key(0x04), key(0x05)
+	kemShared, encapsulation := fill(0x02, mlkem.SharedKeySize), fill(0x03, mlkem.CiphertextSize768)
+
+	// HKDF-SHA256 with no salt, ikm = kemShared || sharedKey, and
+	// info = "lockbox x25519-mlkem768" || encapsulation || sender || peer.
+	combined := combineHybrid(sharedKey, kemShared, encapsulation, sender, peer)
+	assert.Equal(t, hex.EncodeToString(combined[:]), "49f3248a930f5896d22acab6e0fbc3171cf45c18d0cb20458734758dbc99bae1")
+
+	// Every part of the transcript changes the key.
+	for name, other := range map[string]*[keySize]byte{
+		"shared key":    combineHybrid(key(0xff), kemShared, encapsulation, sender, peer),
+		"kem shared":    combineHybrid(sharedKey, fill(0xff, mlkem.SharedKeySize), encapsulation, sender, peer),
+		"encapsulation": combineHybrid(sharedKey, kemShared, fill(0xff, mlkem.CiphertextSize768), sender, peer),
+		"sender":        combineHybrid(sharedKey, kemShared, encapsulation, key(0xff), peer),
+		"peer":          combineHybrid(sharedKey, kemShared, encapsulation, sender, key(0xff)),
+	} {
+		assert.Assert(t, *other != *combined, name)
+	}
+}
@@ -23,5 +23,5 @@ var (
 )
 
 func init() {
-	SchemeBuilder.Register(&Lockbox{}, &LockboxList{})
+	SchemeBuilder.Register(&Lockbox{}, &LockboxList{}, &LockboxKey{}, &LockboxKeyList{})
 }
@@ -0,0 +1,181 @@
+package v1
+
+import (
+	"crypto/mlkem"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/kevinburke/nacl"
+	"github.com/kevinburke/nacl/box"
+	"github.com/kevinburke/nacl/secretbox"
+	"golang.org/x/crypto/hkdf"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// AlgorithmX25519MLKEM768 seals the data key with both Curve25519 and ML-KEM-768, so
+// that unlocking requires breaking both. Values are encrypted with the data key as in
+// envelope encrypted nacl-box Lockboxes.
+const AlgorithmX25519MLKEM768 = "x25519-mlkem768"
+
+// HybridPublicKeySize is the length of a hybrid public key: a Curve25519 public key
+// followed by an ML-KEM-768 encapsulation key.
+const HybridPublicKeySize = keySize + mlkem.EncapsulationKeySize768
+
+func init() {
+	RegisterDecryptor(AlgorithmX25519MLKEM768, hybrid{})
+}
+
+// ErrNoDecapsulationKey is returned when a private key has no ML-KEM-768 decapsulation key,
+// such as keypairs created before AlgorithmX25519MLKEM768 was supported.
+var ErrNoDecapsulationKey = errors.New("private key has no ML-KEM-768 decapsulation key")
+
+// Decapsulator is a KeyAgreement that also holds an ML-KEM-768 decapsulation key, as
+// required to unlock AlgorithmX25519MLKEM768 Lockboxes. The decapsulation key is generated
+// independently of the Curve25519 private key, so that breaking one doesn't reveal the
+// other.
+// +kubebuilder:object:generate=false
+type Decapsulator interface {
+	KeyAgreement
+
+	// DecapsulationKey returns the ML-KEM-768 decapsulation key, or ErrNoDecapsulationKey
+	// if there's none.
+	DecapsulationKey() (*mlkem.DecapsulationKey768, error)
+}
+
+// decapsulationKey returns the key's ML-KEM-768 decapsulation key.
+func decapsulationKey(key KeyAgreement) (*mlkem.DecapsulationKey768, error) {
+	d, ok := key.(Decapsulator)
+	if !ok {
+		return nil, ErrNoDecapsulationKey
+	}
+
+	return d.DecapsulationKey()
+}
+
+// HybridPublicKey returns the hybrid public key for the private key, as served by the
+// controller and parsed by ParseHybridPublicKey. The key must be a Decapsulator.
+func HybridPublicKey(pub nacl.Key, key KeyAgreement) ([]byte, error) {
+	dk, err := decapsulationKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append([]byte{}, pub[:]...), dk.EncapsulationKey().Bytes()...), nil
+}
+
+// ParseHybridPublicKey splits a hybrid public key into its Curve25519 public key and
+// ML-KEM-768 encapsulation key.
+func ParseHybridPublicKey(b []byte) (nacl.Key, *mlkem.EncapsulationKey768, error) {
+	if len(b) != HybridPublicKeySize {
+		return nil, nil, fmt.Errorf("incorrect hybrid public key length: %d, should be %d", len(b), HybridPublicKeySize)
+	}
+
+	ek, err := mlkem.NewEncapsulationKey768(b[keySize:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pub := new([keySize]byte)
+	copy(pub[:], b[:keySize])
+	return pub, ek, nil
+}
+
+// NewHybridFromSecret creates a Lockbox with the AlgorithmX25519MLKEM768 algorithm,
+// sealing the data key for the peer's Curve25519 public key and encapsulation key.
+func NewHybridFromSecret(secret corev1.Secret, namespace string, peer nacl.Key, peerKEM *mlkem.EncapsulationKey768, pub, pri nacl.Key) *Lockbox {
+	dataKey := nacl.NewKey()
+
+	b := newFromSecret(secret, namespace, peer, pub, func(value []byte) []byte {
+		return secretbox.EasySeal(value, dataKey)
+	})
+	b.Spec.Version = LockboxVersionEnvelope
+	b.Spec.Algorithm = AlgorithmX25519MLKEM768
+
+	kemShared, encapsulation := peerKEM.Encapsulate()
+	wrapKey := combineHybrid(box.Precompute(peer, pri), kemShared, encapsulation, pub, peer)
+	b.Spec.Recipients = []LockboxRecipient{{
+		Peer:          peer[:],
+		Key:           secretbox.EasySeal(dataKey[:], wrapKey),
+		Encapsulation: encapsulation,
+	}}
+
+	return b
+}
+
+// combineHybrid derives the key sealing the data key from both shared secrets, binding the
+// full transcript: the ML-KEM ciphertext, the sender's ephemeral Curve25519 public key, and
+// the recipient's Curve25519 public key, as in X-Wing.
+func combineHybrid(sharedKey nacl.Key, kemShared, encapsulation []byte, sender, peer nacl.Key) nacl.Key {
+	ikm := append(append([]byte{}, kemShared...), sharedKey[:]...)
+
+	info := []byte("lockbox " + AlgorithmX25519MLKEM768)
+	info = append(info, encapsulation...)
+	info = append(info, sender[:]...)
+	info = append(info, peer[:]...)
+
+	key := new([keySize]byte)
+	r := hkdf.New(sha256.New, ikm, nil, info)
+	if _, err := io.ReadFull(r, key[:]); err != nil {
+		// HKDF can produce up to 255 hashes of output, far more than a key.
+		panic(err)
+	}
+
+	return key
+}
+
+// hybrid implements Decryptor for AlgorithmX25519MLKEM768.
+type hybrid struct{}
+
+// DataKey implements dataKeyDecryptor
+func (hybrid) DataKey(lb *Lockbox, peer nacl.Key, key KeyAgreement) (nacl.Key, error) {
+	sender := lb.SenderFor(peer)
+	if sender == nil {
+		return nil, invalidKeyLengthError{name: "sender"}
+	}
+
+	r := lb.recipient(peer)
+	if r == nil {
+		return nil, fmt.Errorf("lockbox has no recipient for peer key %x", peer[:])
+	}
+	if len(r.Encapsulation) != mlkem.CiphertextSize768 {
+		return nil, fmt.Errorf("incorrect encapsulation length: %d, should be %d", len(r.Encapsulation), mlkem.CiphertextSize768)
+	}
+
+	sharedKey, err := key.SharedKey(sender)
+	if err != nil {
+		return nil, keyAgreementError{error: err}
+	}
+
+	dk, err := decapsulationKey(key)
+	if err != nil {
+		return nil, err
+	}
+	kemShared, err := dk.Decapsulate(r.Encapsulation)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := secretbox.EasyOpen(r.Key, combineHybrid(sharedKey, kemShared, r.Encapsulation, sender, peer))
+	if err != nil {
+		return nil, fmt.Errorf("unable to open data key: %w", err)
+	}
+	if len(dataKey) != keySize {
+		return nil, fmt.Errorf("incorrect data key length: %d, should be %d", len(dataKey), keySize)
+	}
+
+	k := new([keySize]byte)
+	copy(k[:], dataKey)
+	return k, nil
+}
+
+// Opener implements Decryptor
+func (d hybrid) Opener(lb *Lockbox, peer nacl.Key, key KeyAgreement) (Opener, error) {
+	dataKey, err := d.DataKey(lb, peer, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return DataKeyOpener(dataKey), nil
+}
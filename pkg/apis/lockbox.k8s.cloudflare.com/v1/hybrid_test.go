@@ -0,0 +1,74 @@
+package v1_test
+
+import (
+	"crypto/mlkem"
+	"crypto/rand"
+	"testing"
+
+	v1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"github.com/kevinburke/nacl"
+	"github.com/kevinburke/nacl/box"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// hybridKey implements v1.Decapsulator for tests.
+type hybridKey struct {
+	privateKey
+	dk *mlkem.DecapsulationKey768
+}
+
+func (h hybridKey) DecapsulationKey() (*mlkem.DecapsulationKey768, error) {
+	return h.dk, nil
+}
+
+func newHybridKey(t *testing.T, pri nacl.Key) hybridKey {
+	dk, err := mlkem.GenerateKey768()
+	assert.NilError(t, err)
+	return hybridKey{privateKey: privateKey{pri}, dk: dk}
+}
+
+func TestLockUnlockHybrid(t *testing.T) {
+	senderPubKey, senderPriKey, _ := box.GenerateKey(rand.Reader)
+	serverPubKey, serverPriKey, _ := box.GenerateKey(rand.Reader)
+	serverKey := newHybridKey(t, serverPriKey)
+
+	hybridPub, err := v1.HybridPublicKey(serverPubKey, serverKey)
+	assert.NilError(t, err)
+	assert.Equal(t, len(hybridPub), v1.HybridPublicKeySize)
+
+	peerKey, peerKEM, err := v1.ParseHybridPublicKey(hybridPub)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, peerKey, serverPubKey)
+
+	secret := corev1.Secret{
+		Data: map[string][]byte{
+			"test": []byte("test"),
+		},
+	}
+
+	lb := v1.NewHybridFromSecret(secret, "namespace", peerKey, peerKEM, senderPubKey, senderPriKey)
+	assert.Equal(t, lb.Spec.Algorithm, v1.AlgorithmX25519MLKEM768)
+	assert.Equal(t, len(lb.Spec.Recipients), 1)
+
+	o, err := lb.Opener(serverPubKey, serverKey)
+	assert.NilError(t, err)
+	data, err := lb.UnlockShared(o)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, data, secret.Data)
+
+	// The decapsulation key isn't derived from the Curve25519 private key.
+	_, err = lb.Opener(serverPubKey, newHybridKey(t, serverPriKey))
+	assert.ErrorContains(t, err, "unable to open data key")
+	assert.ErrorIs(t, lb.UnlockInto(&corev1.Secret{}, serverPriKey), v1.ErrNoDecapsulationKey)
+
+	// Both halves are needed to open the data key.
+	tampered := lb.DeepCopy()
+	otherKey := newHybridKey(t, serverPriKey)
+	_, tampered.Spec.Recipients[0].Encapsulation = otherKey.dk.EncapsulationKey().Encapsulate()
+	_, err = tampered.Opener(serverPubKey, serverKey)
+	assert.ErrorContains(t, err, "unable to open data key")
+
+	// Recipients sealed with Curve25519 alone would weaken the Lockbox.
+	assert.ErrorContains(t, lb.AddRecipient(nacl.NewKey(), senderPubKey), v1.AlgorithmX25519MLKEM768)
+}
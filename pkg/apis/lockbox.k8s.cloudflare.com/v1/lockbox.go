@@ -1,28 +1,86 @@
 package v1
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
 	"github.com/kevinburke/nacl"
 	"github.com/kevinburke/nacl/box"
+	"github.com/kevinburke/nacl/scalarmult"
+	"github.com/kevinburke/nacl/secretbox"
+	"golang.org/x/crypto/curve25519"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 )
 
 const keySize = nacl.KeySize
 
-// NewFromSecret creates a Lockbox wrapping the provided Secret. The value of each secret
-// are individually encrypted using the provided key pair.
+const (
+	// ProjectionLabel is set on immutable Secrets using the Hash strategy to the
+	// name of the projection they were created from.
+	ProjectionLabel = "lockbox.k8s.cloudflare.com/projection"
+
+	// SupersededAnnotation records when a Secret using the Hash strategy was replaced
+	// by a newer Secret, in RFC 3339 format.
+	SupersededAnnotation = "lockbox.k8s.cloudflare.com/superseded-at"
+)
+
+// NewFromSecret creates a Lockbox wrapping the provided Secret. Each secret value is
+// encrypted with a random data key, which is sealed for the peer using the provided
+// key pair.
 func NewFromSecret(secret corev1.Secret, namespace string, peer, pub, pri nacl.Key) *Lockbox {
-	encNS := box.EasySeal([]byte(namespace), peer, pri)
+	return NewFromSecretWithRecipients(secret, namespace, []nacl.Key{peer}, pub, pri)
+}
+
+// NewLegacyFromSecret creates a Lockbox wrapping the provided Secret in the legacy format.
+// The value of each secret are individually encrypted using the provided key pair.
+func NewLegacyFromSecret(secret corev1.Secret, namespace string, peer, pub, pri nacl.Key) *Lockbox {
+	b := newFromSecret(secret, namespace, peer, pub, func(value []byte) []byte {
+		return box.EasySeal(value, peer, pri)
+	})
+	b.Spec.Version = LockboxVersionLegacy
+
+	return b
+}
+
+// NewFromSecretWithRecipients creates a Lockbox wrapping the provided Secret that can be
+// unlocked by any of the peers. Each secret value is encrypted with a random data key,
+// which is sealed for each peer using the provided key pair. The first peer is stored as
+// the Lockbox's Peer, and is expected to be the Lockbox controller's public key.
+func NewFromSecretWithRecipients(secret corev1.Secret, namespace string, peers []nacl.Key, pub, pri nacl.Key) *Lockbox {
+	dataKey := nacl.NewKey()
+
+	b := newFromSecret(secret, namespace, peers[0], pub, func(value []byte) []byte {
+		return secretbox.EasySeal(value, dataKey)
+	})
+	b.Spec.Version = LockboxVersionEnvelope
+	for _, peer := range peers {
+		b.Spec.Recipients = append(b.Spec.Recipients, LockboxRecipient{
+			Peer: peer[:],
+			Key:  box.EasySeal(dataKey[:], peer, pri),
+		})
+	}
+
+	return b
+}
 
+// newFromSecret creates a Lockbox wrapping the Secret, encrypting the namespace and each
+// secret value with seal.
+func newFromSecret(secret corev1.Secret, namespace string, peer, pub nacl.Key, seal func([]byte) []byte) *Lockbox {
 	b := &Lockbox{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      secret.Name,
 			Namespace: namespace,
 		},
 		Spec: LockboxSpec{
-			Sender:    pub[:],
+			Algorithm: AlgorithmNaClBox,
 			Peer:      peer[:],
-			Namespace: encNS,
+			Namespace: seal([]byte(namespace)),
 			Data:      map[string][]byte{},
 			Template: LockboxSecretTemplate{
 				LockboxSecretTemplateMetadata: LockboxSecretTemplateMetadata{
@@ -34,39 +92,294 @@ func NewFromSecret(secret corev1.Secret, namespace string, peer, pub, pri nacl.K
 		},
 	}
 
+	if pub != nil {
+		b.Spec.Sender = pub[:]
+	}
+
 	for key, value := range secret.Data {
-		enc := box.EasySeal(value, peer, pri)
-		b.Spec.Data[key] = enc
+		b.Spec.Data[key] = seal(value)
 	}
 
 	for key, value := range secret.StringData {
-		enc := box.EasySeal([]byte(value), peer, pri)
-		b.Spec.Data[key] = enc
+		b.Spec.Data[key] = seal([]byte(value))
 	}
 
 	return b
 }
 
-// UnlockInto decrypts each secret value into the provided secret.
+// Peers returns the public keys that can unlock this Lockbox: each recipient's peer key,
+// or the Lockbox's Peer if there are no recipients.
+func (in *Lockbox) Peers() [][]byte {
+	if len(in.Spec.Recipients) == 0 {
+		return [][]byte{in.Spec.Peer}
+	}
+
+	peers := make([][]byte, 0, len(in.Spec.Recipients))
+	for _, r := range in.Spec.Recipients {
+		peers = append(peers, r.Peer)
+	}
+	return peers
+}
+
+// IsEnvelope reports whether the Lockbox's values are encrypted with a data key sealed
+// for each recipient, rather than for the Peer directly.
+func (in *Lockbox) IsEnvelope() bool {
+	return in.Spec.Version == LockboxVersionEnvelope || len(in.Spec.Recipients) > 0
+}
+
+// SenderFor returns the public key the peer's data key was sealed with, or nil if the
+// key is missing or invalid.
+func (in *Lockbox) SenderFor(peer nacl.Key) nacl.Key {
+	sender := in.Spec.Sender
+	if r := in.recipient(peer); r != nil && len(r.Sender) > 0 {
+		sender = r.Sender
+	}
+
+	if len(sender) != keySize {
+		return nil
+	}
+	key := new([keySize]byte)
+	copy(key[:], sender)
+	return key
+}
+
+// DataKey returns the key the namespace and secret values are encrypted with, for the
+// recipient with the peer public key. For legacy Lockboxes, this is the shared key
+// between the Sender and the peer.
+func (in *Lockbox) DataKey(peer nacl.Key, key KeyAgreement) (nacl.Key, error) {
+	d, err := in.Decryptor()
+	if err != nil {
+		return nil, err
+	}
+
+	dkd, ok := d.(dataKeyDecryptor)
+	if !ok {
+		return nil, fmt.Errorf("%s lockboxes have no data key", in.Spec.Algorithm)
+	}
+	return dkd.DataKey(in, peer, key)
+}
+
+// Opener returns an Opener for the namespace and secret values, for the recipient with
+// the peer public key.
+func (in *Lockbox) Opener(peer nacl.Key, key KeyAgreement) (Opener, error) {
+	d, err := in.Decryptor()
+	if err != nil {
+		return nil, err
+	}
+
+	return d.Opener(in, peer, key)
+}
+
+// OpenNamespace decrypts the namespace this Lockbox was locked for.
+func (in *Lockbox) OpenNamespace(o Opener) (string, error) {
+	namespace, err := o.Open(in.Spec.Namespace)
+	return string(namespace), err
+}
+
+// AddRecipient seals the data key for another peer, using a new sender key pair so the
+// original sender's private key isn't needed. The secret values are unchanged. Adding a
+// peer that's already a recipient replaces its entry.
+func (in *Lockbox) AddRecipient(dataKey, peer nacl.Key) error {
+	if !in.IsEnvelope() {
+		return fmt.Errorf("recipients can't be added to legacy lockboxes")
+	}
+	if in.Spec.Algorithm != "" && in.Spec.Algorithm != AlgorithmNaClBox {
+		return fmt.Errorf("recipients can't be added to %s lockboxes", in.Spec.Algorithm)
+	}
+
+	pub, pri, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	in.RemoveRecipient(peer)
+	in.Spec.Recipients = append(in.Spec.Recipients, LockboxRecipient{
+		Sender: pub[:],
+		Peer:   peer[:],
+		Key:    box.EasySeal(dataKey[:], peer, pri),
+	})
+	return nil
+}
+
+// RemoveRecipient removes the peer's copy of the data key, reporting whether the peer was
+// a recipient. Removing a recipient doesn't re-encrypt the secret values, so a removed
+// recipient that kept the data key can still unlock them.
+func (in *Lockbox) RemoveRecipient(peer nacl.Key) bool {
+	for i, r := range in.Spec.Recipients {
+		if nacl.Verify(r.Peer, peer[:]) {
+			in.Spec.Recipients = append(in.Spec.Recipients[:i], in.Spec.Recipients[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// privateKey implements KeyAgreement for a private key held in memory.
+type privateKey struct {
+	pri nacl.Key
+}
+
+// SharedKey implements KeyAgreement
+func (p privateKey) SharedKey(peer nacl.Key) (nacl.Key, error) {
+	return box.Precompute(peer, p.pri), nil
+}
+
+// X25519 implements X25519KeyAgreement
+func (p privateKey) X25519(peer nacl.Key) ([]byte, error) {
+	return curve25519.X25519(p.pri[:], peer[:])
+}
+
+// recipient returns the recipient entry for the peer, if any.
+func (in *Lockbox) recipient(peer nacl.Key) *LockboxRecipient {
+	for i, r := range in.Spec.Recipients {
+		if len(r.Peer) == keySize && nacl.Verify(r.Peer, peer[:]) {
+			return &in.Spec.Recipients[i]
+		}
+	}
+
+	return nil
+}
+
+// UnlockInto decrypts each secret value into the provided secret. Any data templates
+// are rendered after decryption, and may refer to any of the decrypted values.
+//
+// Projections are ignored; the secret is populated with all unlocked data.
 func (in *Lockbox) UnlockInto(secret *corev1.Secret, pri nacl.Key) error {
-	sender := new([keySize]byte)
-	copy(sender[:], in.Spec.Sender)
+	data, err := in.Unlock(pri)
+	if err != nil {
+		return err
+	}
+
+	projection := LockboxSecretProjection{
+		LockboxSecretTemplateMetadata: in.Spec.Template.LockboxSecretTemplateMetadata,
+		Type:                          in.Spec.Template.Type,
+	}
+	return projection.ProjectInto(secret, data)
+}
+
+// Unlock decrypts and returns each secret value, along with any rendered data templates.
+// For Lockboxes with recipients, the private key may belong to any recipient.
+func (in *Lockbox) Unlock(pri nacl.Key) (map[string][]byte, error) {
+	o, err := in.Opener(scalarmult.Base(pri), privateKey{pri: pri})
+	if err != nil {
+		return nil, err
+	}
+
+	return in.UnlockShared(o)
+}
 
+// UnlockShared is like Unlock, but takes the Opener returned by Opener. This allows
+// unlocking without direct access to the peer's private key.
+func (in *Lockbox) UnlockShared(o Opener) (map[string][]byte, error) {
 	data := make(map[string][]byte, len(in.Spec.Data))
 	for key, val := range in.Spec.Data {
-		d, err := box.EasyOpen(val, sender, pri)
+		v, err := o.Open(val)
 		if err != nil {
-			return decryptSecretKeyError{error: err, key: key}
+			return nil, decryptSecretKeyError{error: err, key: key}
+		}
+		data[key] = v
+	}
+
+	rendered, err := renderTemplates(in.Spec.Template.Data, data)
+	if err != nil {
+		return nil, err
+	}
+	for key, val := range rendered {
+		data[key] = val
+	}
+
+	return data, nil
+}
+
+// SecretProjections returns the projections describing each Secret controlled by
+// this Lockbox. Without explicit projections, a single projection named after the
+// Lockbox including all data is returned. Projections must have unique, non-empty names,
+// so that no two projections write the same Secret.
+func (in *Lockbox) SecretProjections() ([]LockboxSecretProjection, error) {
+	if len(in.Spec.Template.Projections) == 0 {
+		return []LockboxSecretProjection{
+			{
+				Name:                          in.Name,
+				LockboxSecretTemplateMetadata: in.Spec.Template.LockboxSecretTemplateMetadata,
+				Type:                          in.Spec.Template.Type,
+				Immutable:                     in.Spec.Template.Immutable,
+			},
+		}, nil
+	}
+
+	names := make(map[string]struct{}, len(in.Spec.Template.Projections))
+	for i, projection := range in.Spec.Template.Projections {
+		if projection.Name == "" {
+			return nil, fmt.Errorf("projection %d has no name", i)
+		}
+		if _, ok := names[projection.Name]; ok {
+			return nil, fmt.Errorf("duplicate projection name %q", projection.Name)
 		}
+		names[projection.Name] = struct{}{}
+	}
 
-		data[key] = d
+	return in.Spec.Template.Projections, nil
+}
+
+// SecretName returns the name of the Secret created for the projection of data, which
+// should be the unlocked data of the Lockbox. Immutable projections using the Hash strategy
+// are suffixed with a hash of the projected data, so that each change to the data creates a
+// new Secret, while re-sealing unchanged data keeps the same name.
+func (in *Lockbox) SecretName(p *LockboxSecretProjection, data map[string][]byte) string {
+	if p.Immutable == nil || p.Immutable.Strategy == ImmutableStrategyRecreate {
+		return p.Name
 	}
 
-	secret.Data = data
-	secret.Type = in.Spec.Template.Type
-	secret.Labels = in.Spec.Template.Labels
-	secret.Annotations = in.Spec.Template.Annotations
+	return p.Name + "-" + in.contentHash(p, data)
+}
 
+// contentHash returns a short hash identifying the contents of a projected Secret. The
+// Lockbox's UID is included, so the hash can't be compared against precomputed hashes of
+// common values.
+func (in *Lockbox) contentHash(p *LockboxSecretProjection, data map[string][]byte) string {
+	secret := &corev1.Secret{}
+	_ = p.ProjectInto(secret, data)
+
+	h := sha256.New()
+	_ = json.NewEncoder(h).Encode(struct {
+		UID  types.UID
+		Data map[string][]byte
+		Type corev1.SecretType
+	}{in.UID, secret.Data, p.Type})
+
+	return hex.EncodeToString(h.Sum(nil))[:10]
+}
+
+// ProjectInto populates the provided secret with the selected keys of data, which
+// should be the unlocked data of a Lockbox.
+func (p *LockboxSecretProjection) ProjectInto(secret *corev1.Secret, data map[string][]byte) error {
+	projected := make(map[string][]byte, len(data))
+	if len(p.Keys) == 0 {
+		for key, val := range data {
+			projected[key] = val
+		}
+	}
+	for _, kp := range p.Keys {
+		val, ok := data[kp.Key]
+		if !ok {
+			return fmt.Errorf("projected key %q not found in lockbox data", kp.Key)
+		}
+
+		name := kp.Name
+		if name == "" {
+			name = kp.Key
+		}
+		projected[name] = val
+	}
+
+	secret.Data = projected
+	secret.Type = p.Type
+	secret.Labels = p.Labels
+	secret.Annotations = p.Annotations
+	if p.Immutable != nil {
+		secret.Immutable = ptr.To(true)
+	}
 	return nil
 }
 
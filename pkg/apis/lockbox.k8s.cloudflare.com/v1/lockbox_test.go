@@ -144,6 +144,200 @@ func TestLockUnlock(t *testing.T) {
 	assert.DeepEqual(t, unlockedSecret, expectedSecret)
 }
 
+func TestLockUnlockRecipients(t *testing.T) {
+	senderPubKey, senderPriKey, _ := box.GenerateKey(rand.Reader)
+	serverPubKey, serverPriKey, _ := box.GenerateKey(rand.Reader)
+	escrowPubKey, escrowPriKey, _ := box.GenerateKey(rand.Reader)
+	_, otherPriKey, _ := box.GenerateKey(rand.Reader)
+
+	secret := corev1.Secret{
+		Data: map[string][]byte{
+			"test": {0x74, 0x65, 0x73, 0x74},
+		},
+	}
+
+	lb := v1.NewFromSecretWithRecipients(secret, "namespace", []nacl.Key{serverPubKey, escrowPubKey}, senderPubKey, senderPriKey)
+	assert.DeepEqual(t, lb.Spec.Peer, serverPubKey[:])
+	assert.DeepEqual(t, lb.Peers(), [][]byte{serverPubKey[:], escrowPubKey[:]})
+
+	expectedSecret := &corev1.Secret{
+		Data: map[string][]byte{
+			"test": {0x74, 0x65, 0x73, 0x74},
+		},
+	}
+
+	for _, pri := range []nacl.Key{serverPriKey, escrowPriKey} {
+		unlockedSecret := &corev1.Secret{}
+		assert.NilError(t, lb.UnlockInto(unlockedSecret, pri))
+		assert.DeepEqual(t, unlockedSecret, expectedSecret)
+	}
+
+	err := lb.UnlockInto(&corev1.Secret{}, otherPriKey)
+	assert.ErrorContains(t, err, "no recipient for peer key")
+}
+
+func TestLockUnlockLegacy(t *testing.T) {
+	senderPubKey, senderPriKey, _ := box.GenerateKey(rand.Reader)
+	serverPubKey, serverPriKey, _ := box.GenerateKey(rand.Reader)
+
+	secret := corev1.Secret{
+		Data: map[string][]byte{
+			"test": {0x74, 0x65, 0x73, 0x74},
+		},
+	}
+
+	lb := v1.NewLegacyFromSecret(secret, "namespace", serverPubKey, senderPubKey, senderPriKey)
+	assert.Equal(t, lb.Spec.Version, v1.LockboxVersionLegacy)
+	assert.Assert(t, !lb.IsEnvelope())
+
+	unlockedSecret := &corev1.Secret{}
+	assert.NilError(t, lb.UnlockInto(unlockedSecret, serverPriKey))
+	assert.DeepEqual(t, unlockedSecret.Data, secret.Data)
+
+	assert.ErrorContains(t, lb.AddRecipient(nacl.NewKey(), serverPubKey), "legacy")
+}
+
+func TestAddRecipient(t *testing.T) {
+	senderPubKey, senderPriKey, _ := box.GenerateKey(rand.Reader)
+	serverPubKey, serverPriKey, _ := box.GenerateKey(rand.Reader)
+	newPubKey, newPriKey, _ := box.GenerateKey(rand.Reader)
+
+	secret := corev1.Secret{
+		Data: map[string][]byte{
+			"test": {0x74, 0x65, 0x73, 0x74},
+		},
+	}
+
+	lb := v1.NewFromSecret(secret, "namespace", serverPubKey, senderPubKey, senderPriKey)
+	assert.Equal(t, lb.Spec.Version, v1.LockboxVersionEnvelope)
+	assert.Equal(t, len(lb.Spec.Recipients), 1)
+	sealed := lb.Spec.DeepCopy().Data
+
+	dataKey, err := lb.DataKey(serverPubKey, privateKey{serverPriKey})
+	assert.NilError(t, err)
+	assert.NilError(t, lb.AddRecipient(dataKey, newPubKey))
+	assert.Assert(t, lb.RemoveRecipient(serverPubKey))
+
+	// Re-keying only touches the recipients, not the sealed values.
+	assert.DeepEqual(t, lb.Spec.Data, sealed)
+	assert.Equal(t, len(lb.Spec.Recipients), 1)
+
+	unlockedSecret := &corev1.Secret{}
+	assert.NilError(t, lb.UnlockInto(unlockedSecret, newPriKey))
+	assert.DeepEqual(t, unlockedSecret.Data, secret.Data)
+
+	err = lb.UnlockInto(&corev1.Secret{}, serverPriKey)
+	assert.ErrorContains(t, err, "no recipient for peer key")
+}
+
+func TestLockUnlockTemplate(t *testing.T) {
+	senderPubKey, senderPriKey, _ := box.GenerateKey(rand.Reader)
+	serverPubKey, serverPriKey, _ := box.GenerateKey(rand.Reader)
+
+	secret := corev1.Secret{
+		Data: map[string][]byte{
+			"username": []byte("gopher"),
+			"password": []byte("hunter2"),
+		},
+	}
+
+	lb := v1.NewFromSecret(secret, "namespace", serverPubKey, senderPubKey, senderPriKey)
+	lb.Spec.Template.Data = map[string]string{
+		"dsn":  "postgres://{{ .username }}:{{ .password }}@db:5432/app",
+		"auth": `{{ printf "%s:%s" .username .password | b64enc }}`,
+	}
+
+	unlockedSecret := &corev1.Secret{}
+	expectedSecret := &corev1.Secret{
+		Data: map[string][]byte{
+			"username": []byte("gopher"),
+			"password": []byte("hunter2"),
+			"dsn":      []byte("postgres://gopher:hunter2@db:5432/app"),
+			"auth":     []byte("Z29waGVyOmh1bnRlcjI="),
+		},
+	}
+
+	assert.NilError(t, lb.UnlockInto(unlockedSecret, serverPriKey))
+	assert.DeepEqual(t, unlockedSecret, expectedSecret)
+}
+
+func TestLockUnlockTemplateErr(t *testing.T) {
+	senderPubKey, senderPriKey, _ := box.GenerateKey(rand.Reader)
+	serverPubKey, serverPriKey, _ := box.GenerateKey(rand.Reader)
+
+	secret := corev1.Secret{
+		Data: map[string][]byte{
+			"username": []byte("gopher"),
+		},
+	}
+
+	lb := v1.NewFromSecret(secret, "namespace", serverPubKey, senderPubKey, senderPriKey)
+	lb.Spec.Template.Data = map[string]string{
+		"dsn": "postgres://{{ .username }}:{{ .password }}@db:5432/app",
+	}
+
+	unlockedSecret := &corev1.Secret{}
+	err := lb.UnlockInto(unlockedSecret, serverPriKey)
+	assert.ErrorContains(t, err, `map has no entry for key "password"`)
+	assert.DeepEqual(t, unlockedSecret, &corev1.Secret{})
+}
+
+func TestProjectInto(t *testing.T) {
+	data := map[string][]byte{
+		"cert": []byte("certificate"),
+		"key":  []byte("private key"),
+	}
+
+	projection := v1.LockboxSecretProjection{
+		Name: "example-tls",
+		Type: corev1.SecretTypeTLS,
+		Keys: []v1.LockboxKeyProjection{
+			{Key: "cert", Name: corev1.TLSCertKey},
+			{Key: "key", Name: corev1.TLSPrivateKeyKey},
+		},
+	}
+
+	secret := &corev1.Secret{}
+	expected := &corev1.Secret{
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       []byte("certificate"),
+			corev1.TLSPrivateKeyKey: []byte("private key"),
+		},
+	}
+	assert.NilError(t, projection.ProjectInto(secret, data))
+	assert.DeepEqual(t, secret, expected)
+
+	projection.Keys = append(projection.Keys, v1.LockboxKeyProjection{Key: "ca"})
+	assert.ErrorContains(t, projection.ProjectInto(&corev1.Secret{}, data), `projected key "ca" not found`)
+}
+
+func TestSecretProjections(t *testing.T) {
+	lb := &v1.Lockbox{
+		ObjectMeta: metav1.ObjectMeta{Name: "example"},
+		Spec: v1.LockboxSpec{
+			Template: v1.LockboxSecretTemplate{Type: corev1.SecretTypeOpaque},
+		},
+	}
+
+	projections, err := lb.SecretProjections()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, projections, []v1.LockboxSecretProjection{{Name: "example", Type: corev1.SecretTypeOpaque}})
+
+	lb.Spec.Template.Projections = []v1.LockboxSecretProjection{{Name: "example-tls"}, {Name: "example-auth"}}
+	projections, err = lb.SecretProjections()
+	assert.NilError(t, err)
+	assert.Equal(t, len(projections), 2)
+
+	lb.Spec.Template.Projections = append(lb.Spec.Template.Projections, v1.LockboxSecretProjection{Name: "example-tls"})
+	_, err = lb.SecretProjections()
+	assert.ErrorContains(t, err, `duplicate projection name "example-tls"`)
+
+	lb.Spec.Template.Projections = []v1.LockboxSecretProjection{{Name: "example-tls"}, {}}
+	_, err = lb.SecretProjections()
+	assert.ErrorContains(t, err, "projection 1 has no name")
+}
+
 func loadKeypair(t *testing.T, pub, pri string) (pubKey, priKey nacl.Key, err error) {
 	t.Helper()
 
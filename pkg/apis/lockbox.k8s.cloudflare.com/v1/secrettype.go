@@ -0,0 +1,113 @@
+package v1
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// requiredSecretKeys lists the data keys the API server requires for built-in Secret types.
+var requiredSecretKeys = map[corev1.SecretType][]string{
+	corev1.SecretTypeTLS:              {corev1.TLSCertKey, corev1.TLSPrivateKeyKey},
+	corev1.SecretTypeDockerConfigJson: {corev1.DockerConfigJsonKey},
+	corev1.SecretTypeDockercfg:        {corev1.DockerConfigKey},
+	corev1.SecretTypeSSHAuth:          {corev1.SSHAuthPrivateKey},
+}
+
+// requiredSecretAnnotations lists the annotations the API server requires for built-in
+// Secret types.
+var requiredSecretAnnotations = map[corev1.SecretType][]string{
+	corev1.SecretTypeServiceAccountToken: {corev1.ServiceAccountNameKey},
+}
+
+// MissingSecretKeys returns the data keys required by the Secret type that are absent from
+// data. Basic authentication Secrets need at least one of a username or password, and both
+// are returned if neither is present.
+func MissingSecretKeys(secretType corev1.SecretType, data map[string][]byte) []string {
+	if secretType == corev1.SecretTypeBasicAuth {
+		_, username := data[corev1.BasicAuthUsernameKey]
+		_, password := data[corev1.BasicAuthPasswordKey]
+		if !username && !password {
+			return []string{corev1.BasicAuthUsernameKey, corev1.BasicAuthPasswordKey}
+		}
+		return nil
+	}
+
+	var missing []string
+	for _, key := range requiredSecretKeys[secretType] {
+		if _, ok := data[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// MissingSecretAnnotations returns the annotations required by the Secret type that are
+// absent or empty in annotations.
+func MissingSecretAnnotations(secretType corev1.SecretType, annotations map[string]string) []string {
+	var missing []string
+	for _, key := range requiredSecretAnnotations[secretType] {
+		if annotations[key] == "" {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// ValidateSecret checks the Secret's data is usable for its built-in type, beyond the keys
+// the API server requires: TLS certificates must match their private key, Docker configs
+// must be well formed, SSH keys must parse, and service account tokens must name their
+// service account. Values in StringData are checked along with Data. Secrets of other types
+// are always valid. Errors never include the data, so are safe to log or report.
+func ValidateSecret(secret *corev1.Secret) error {
+	data := make(map[string][]byte, len(secret.Data)+len(secret.StringData))
+	for key, value := range secret.Data {
+		data[key] = value
+	}
+	for key, value := range secret.StringData {
+		data[key] = []byte(value)
+	}
+
+	if missing := MissingSecretKeys(secret.Type, data); len(missing) > 0 {
+		return fmt.Errorf("%s Secret is missing keys: %s", secret.Type, strings.Join(missing, ", "))
+	}
+	if missing := MissingSecretAnnotations(secret.Type, secret.Annotations); len(missing) > 0 {
+		return fmt.Errorf("%s Secret is missing annotations: %s", secret.Type, strings.Join(missing, ", "))
+	}
+
+	switch secret.Type {
+	case corev1.SecretTypeTLS:
+		if _, err := tls.X509KeyPair(data[corev1.TLSCertKey], data[corev1.TLSPrivateKeyKey]); err != nil {
+			return fmt.Errorf("invalid %s and %s: not a matching certificate and private key", corev1.TLSCertKey, corev1.TLSPrivateKeyKey)
+		}
+	case corev1.SecretTypeDockerConfigJson:
+		var config struct {
+			Auths map[string]json.RawMessage `json:"auths"`
+		}
+		if json.Unmarshal(data[corev1.DockerConfigJsonKey], &config) != nil {
+			return fmt.Errorf("invalid %s: is not valid JSON", corev1.DockerConfigJsonKey)
+		}
+		if config.Auths == nil {
+			return fmt.Errorf("invalid %s: missing auths", corev1.DockerConfigJsonKey)
+		}
+	case corev1.SecretTypeDockercfg:
+		var config map[string]json.RawMessage
+		if json.Unmarshal(data[corev1.DockerConfigKey], &config) != nil {
+			return fmt.Errorf("invalid %s: is not valid JSON", corev1.DockerConfigKey)
+		}
+	case corev1.SecretTypeSSHAuth:
+		// Passphrase protected keys can't be checked further, but are otherwise well formed.
+		_, err := ssh.ParseRawPrivateKey(data[corev1.SSHAuthPrivateKey])
+		var passErr *ssh.PassphraseMissingError
+		if err != nil && !errors.As(err, &passErr) {
+			return fmt.Errorf("invalid %s: is not a valid private key", corev1.SSHAuthPrivateKey)
+		}
+	}
+
+	return nil
+}
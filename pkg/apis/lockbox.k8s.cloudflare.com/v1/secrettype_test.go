@@ -0,0 +1,294 @@
+package v1_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"golang.org/x/crypto/ssh"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMissingSecretKeys(t *testing.T) {
+	type testCase struct {
+		name       string
+		secretType corev1.SecretType
+		data       map[string][]byte
+		expected   []string
+	}
+
+	run := func(t *testing.T, tc testCase) {
+		assert.DeepEqual(t, lockboxv1.MissingSecretKeys(tc.secretType, tc.data), tc.expected)
+	}
+
+	testCases := []testCase{
+		{
+			name:       "opaque",
+			secretType: corev1.SecretTypeOpaque,
+		},
+		{
+			name:       "tls",
+			secretType: corev1.SecretTypeTLS,
+			data:       map[string][]byte{"tls.crt": []byte("cert")},
+			expected:   []string{"tls.key"},
+		},
+		{
+			name:       "dockerconfigjson",
+			secretType: corev1.SecretTypeDockerConfigJson,
+			data:       map[string][]byte{".dockerconfigjson": []byte("{}")},
+		},
+		{
+			name:       "basic auth with password",
+			secretType: corev1.SecretTypeBasicAuth,
+			data:       map[string][]byte{"password": []byte("hunter2")},
+		},
+		{
+			name:       "basic auth without credentials",
+			secretType: corev1.SecretTypeBasicAuth,
+			expected:   []string{"username", "password"},
+		},
+		{
+			name:       "ssh auth",
+			secretType: corev1.SecretTypeSSHAuth,
+			expected:   []string{"ssh-privatekey"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			run(t, tc)
+		})
+	}
+}
+
+func TestMissingSecretAnnotations(t *testing.T) {
+	type testCase struct {
+		name        string
+		secretType  corev1.SecretType
+		annotations map[string]string
+		expected    []string
+	}
+
+	run := func(t *testing.T, tc testCase) {
+		assert.DeepEqual(t, lockboxv1.MissingSecretAnnotations(tc.secretType, tc.annotations), tc.expected)
+	}
+
+	testCases := []testCase{
+		{
+			name:       "opaque",
+			secretType: corev1.SecretTypeOpaque,
+		},
+		{
+			name:        "service account token",
+			secretType:  corev1.SecretTypeServiceAccountToken,
+			annotations: map[string]string{"kubernetes.io/service-account.name": "default"},
+		},
+		{
+			name:        "service account token with empty name",
+			secretType:  corev1.SecretTypeServiceAccountToken,
+			annotations: map[string]string{"kubernetes.io/service-account.name": ""},
+			expected:    []string{"kubernetes.io/service-account.name"},
+		},
+		{
+			name:       "service account token without annotations",
+			secretType: corev1.SecretTypeServiceAccountToken,
+			expected:   []string{"kubernetes.io/service-account.name"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			run(t, tc)
+		})
+	}
+}
+
+func TestValidateSecret(t *testing.T) {
+	cert, key := newTLSKeyPair(t)
+	_, otherKey := newTLSKeyPair(t)
+
+	_, sshPri, err := ed25519.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+	sshKey, err := ssh.MarshalPrivateKey(sshPri, "")
+	assert.NilError(t, err)
+
+	type testCase struct {
+		name     string
+		secret   corev1.Secret
+		expected string
+	}
+
+	run := func(t *testing.T, tc testCase) {
+		err := lockboxv1.ValidateSecret(&tc.secret)
+		if tc.expected == "" {
+			assert.NilError(t, err)
+			return
+		}
+		assert.ErrorContains(t, err, tc.expected)
+	}
+
+	testCases := []testCase{
+		{
+			name: "opaque",
+			secret: corev1.Secret{
+				Type: corev1.SecretTypeOpaque,
+				Data: map[string][]byte{"tls.crt": []byte("cert")},
+			},
+		},
+		{
+			name: "tls",
+			secret: corev1.Secret{
+				Type: corev1.SecretTypeTLS,
+				Data: map[string][]byte{"tls.crt": cert, "tls.key": key},
+			},
+		},
+		{
+			name: "tls from string data",
+			secret: corev1.Secret{
+				Type:       corev1.SecretTypeTLS,
+				Data:       map[string][]byte{"tls.crt": cert},
+				StringData: map[string]string{"tls.key": string(key)},
+			},
+		},
+		{
+			name: "tls missing key",
+			secret: corev1.Secret{
+				Type: corev1.SecretTypeTLS,
+				Data: map[string][]byte{"tls.crt": cert},
+			},
+			expected: "kubernetes.io/tls Secret is missing keys: tls.key",
+		},
+		{
+			name: "tls mismatched key",
+			secret: corev1.Secret{
+				Type: corev1.SecretTypeTLS,
+				Data: map[string][]byte{"tls.crt": cert, "tls.key": otherKey},
+			},
+			expected: "invalid tls.crt and tls.key",
+		},
+		{
+			name: "tls malformed cert",
+			secret: corev1.Secret{
+				Type: corev1.SecretTypeTLS,
+				Data: map[string][]byte{"tls.crt": []byte("cert"), "tls.key": key},
+			},
+			expected: "invalid tls.crt and tls.key",
+		},
+		{
+			name: "dockerconfigjson",
+			secret: corev1.Secret{
+				Type: corev1.SecretTypeDockerConfigJson,
+				Data: map[string][]byte{".dockerconfigjson": []byte(`{"auths":{"registry.example.com":{"auth":"dXNlcjpwYXNz"}}}`)},
+			},
+		},
+		{
+			name: "dockerconfigjson malformed",
+			secret: corev1.Secret{
+				Type: corev1.SecretTypeDockerConfigJson,
+				Data: map[string][]byte{".dockerconfigjson": []byte(`{"auths":`)},
+			},
+			expected: "invalid .dockerconfigjson: is not valid JSON",
+		},
+		{
+			name: "dockerconfigjson without auths",
+			secret: corev1.Secret{
+				Type: corev1.SecretTypeDockerConfigJson,
+				Data: map[string][]byte{".dockerconfigjson": []byte(`{"registry.example.com":{}}`)},
+			},
+			expected: "invalid .dockerconfigjson: missing auths",
+		},
+		{
+			name: "dockercfg",
+			secret: corev1.Secret{
+				Type: corev1.SecretTypeDockercfg,
+				Data: map[string][]byte{".dockercfg": []byte(`{"registry.example.com":{}}`)},
+			},
+		},
+		{
+			name: "dockercfg malformed",
+			secret: corev1.Secret{
+				Type: corev1.SecretTypeDockercfg,
+				Data: map[string][]byte{".dockercfg": []byte(`[]`)},
+			},
+			expected: "invalid .dockercfg",
+		},
+		{
+			name: "basic auth without credentials",
+			secret: corev1.Secret{
+				Type: corev1.SecretTypeBasicAuth,
+			},
+			expected: "kubernetes.io/basic-auth Secret is missing keys: username, password",
+		},
+		{
+			name: "ssh auth",
+			secret: corev1.Secret{
+				Type: corev1.SecretTypeSSHAuth,
+				Data: map[string][]byte{"ssh-privatekey": pem.EncodeToMemory(sshKey)},
+			},
+		},
+		{
+			name: "ssh auth malformed",
+			secret: corev1.Secret{
+				Type: corev1.SecretTypeSSHAuth,
+				Data: map[string][]byte{"ssh-privatekey": []byte("key")},
+			},
+			expected: "invalid ssh-privatekey",
+		},
+		{
+			name: "service account token",
+			secret: corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{"kubernetes.io/service-account.name": "default"},
+				},
+				Type: corev1.SecretTypeServiceAccountToken,
+			},
+		},
+		{
+			name: "service account token without service account",
+			secret: corev1.Secret{
+				Type: corev1.SecretTypeServiceAccountToken,
+			},
+			expected: "missing annotations: kubernetes.io/service-account.name",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			run(t, tc)
+		})
+	}
+}
+
+// newTLSKeyPair returns a PEM encoded self-signed certificate and its private key.
+func newTLSKeyPair(t *testing.T) (cert, key []byte) {
+	t.Helper()
+
+	pri, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &pri.PublicKey, pri)
+	assert.NilError(t, err)
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(pri)
+	assert.NilError(t, err)
+
+	cert = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	key = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return cert, key
+}
@@ -0,0 +1,95 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/base64"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the functions available to secret data templates, in
+// addition to the text/template builtins.
+var templateFuncs = template.FuncMap{
+	"b64enc": func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	},
+	"b64dec": func(s string) (string, error) {
+		b, err := base64.StdEncoding.DecodeString(s)
+		return string(b), err
+	},
+	"quote": strconv.Quote,
+	"trim":  strings.TrimSpace,
+}
+
+// renderTemplates executes each template with the unlocked secret data, returning
+// the rendered values keyed the same as the templates. Templates refer to secret
+// values by key, such as {{ .password }} or {{ index . "tls.key" }}.
+func renderTemplates(templates map[string]string, data map[string][]byte) (map[string][]byte, error) {
+	values := make(map[string]string, len(data))
+	for key, val := range data {
+		values[key] = string(val)
+	}
+
+	rendered := make(map[string][]byte, len(templates))
+	for key, text := range templates {
+		tpl, err := parseTemplate(key, text)
+		if err != nil {
+			return nil, err
+		}
+
+		var buf bytes.Buffer
+		if err := tpl.Execute(&buf, values); err != nil {
+			return nil, renderTemplateError{error: err, key: key}
+		}
+		rendered[key] = buf.Bytes()
+	}
+
+	return rendered, nil
+}
+
+// ParseTemplates parses each template without executing it, returning the first error
+// found, so templates can be checked without the unlocked secret data.
+func ParseTemplates(templates map[string]string) error {
+	keys := make([]string, 0, len(templates))
+	for key := range templates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if _, err := parseTemplate(key, templates[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseTemplate(key, text string) (*template.Template, error) {
+	tpl, err := template.New(key).
+		Funcs(templateFuncs).
+		Option("missingkey=error").
+		Parse(text)
+	if err != nil {
+		return nil, renderTemplateError{error: err, key: key}
+	}
+	return tpl, nil
+}
+
+// renderTemplateError wraps errors while parsing or executing a secret data template.
+// This allows preserving the key for farther error messages.
+type renderTemplateError struct {
+	error
+	key string
+}
+
+// TemplateKey returns the template data key that triggered this error.
+func (e renderTemplateError) TemplateKey() string {
+	return e.key
+}
+
+// Unwrap implements Wrapper, returning the underlying error message.
+func (e renderTemplateError) Unwrap() error {
+	return e.error
+}
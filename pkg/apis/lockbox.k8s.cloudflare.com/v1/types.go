@@ -27,8 +27,21 @@ type Lockbox struct {
 // LockboxSpec is a struct wrapping the encrypted secrets along with the
 // public keys of the sender and server.
 type LockboxSpec struct {
-	// Sender stores the public key used to lock this Lockbox.
-	Sender []byte `json:"sender"`
+	// Version identifies the format this Lockbox was locked with. Unset is
+	// treated as the legacy format, unless Recipients are set.
+	// +kubebuilder:validation:Enum=1;2
+	// +optional
+	Version LockboxVersion `json:"version,omitempty"`
+
+	// Algorithm names the cryptographic construction this Lockbox was locked
+	// with. Unset is treated as nacl-box.
+	// +optional
+	Algorithm string `json:"algorithm,omitempty"`
+
+	// Sender stores the public key used to lock this Lockbox. It's unset for
+	// algorithms that use a new sender key for each value, such as age.
+	// +optional
+	Sender []byte `json:"sender,omitempty"`
 
 	// Peer stores the public key that can unlock this Lockbox.
 	Peer []byte `json:"peer"`
@@ -42,12 +55,49 @@ type LockboxSpec struct {
 	// data map must consist of alphanumeric characters, '-', '_', or '.'.
 	Data map[string][]byte `json:"data"`
 
+	// Recipients, if set, each hold a copy of the data key sealed for a single
+	// public key. The Namespace and Data values are then encrypted with the data
+	// key, and any recipient can unlock this Lockbox.
+	// +optional
+	Recipients []LockboxRecipient `json:"recipients,omitempty"`
+
 	// Template defines the structure of the Secret that will be
 	// created from this Lockbox.
 	// +optional
 	Template LockboxSecretTemplate `json:"template,omitempty"`
 }
 
+// LockboxVersion identifies the format of a Lockbox.
+type LockboxVersion int32
+
+const (
+	// LockboxVersionLegacy Lockboxes encrypt each value from the Sender to the Peer.
+	LockboxVersionLegacy LockboxVersion = 1
+
+	// LockboxVersionEnvelope Lockboxes encrypt each value with a random data key,
+	// which is sealed for each of the Recipients.
+	LockboxVersionEnvelope LockboxVersion = 2
+)
+
+// LockboxRecipient holds the data key of a Lockbox sealed for one public key.
+type LockboxRecipient struct {
+	// Sender stores the public key used to seal the data key, if different from
+	// the Lockbox's Sender. Recipients added after locking have their own Sender.
+	// +optional
+	Sender []byte `json:"sender,omitempty"`
+
+	// Peer stores the public key that can unlock the data key.
+	Peer []byte `json:"peer"`
+
+	// Key stores the data key, encrypted from the Sender to the Peer.
+	Key []byte `json:"key"`
+
+	// Encapsulation stores the ML-KEM ciphertext for the Peer's hybrid public
+	// key, for algorithms combining Curve25519 with ML-KEM.
+	// +optional
+	Encapsulation []byte `json:"encapsulation,omitempty"`
+}
+
 // LockboxSecretTemplate defines structure of API metadata fields
 // of Secrets controlled by a Lockbox.
 type LockboxSecretTemplate struct {
@@ -55,6 +105,59 @@ type LockboxSecretTemplate struct {
 
 	// Type is used to facilitate programmatic handling of secret data.
 	Type corev1.SecretType `json:"type,omitempty"`
+
+	// Data contains Go text/template strings that are rendered with the unlocked
+	// secret data. Each rendered template is stored under its key in the Secret,
+	// replacing any sealed value with the same key.
+	// +optional
+	Data map[string]string `json:"data,omitempty"`
+
+	// Immutable, if set, marks the Secret as immutable. Changes to the Secret's
+	// data are rolled out according to the immutability strategy.
+	// +optional
+	Immutable *LockboxSecretImmutability `json:"immutable,omitempty"`
+
+	// Projections declares Secrets created from a subset of the unlocked data. When
+	// set, a Secret is created for each projection instead of a single Secret
+	// named after the Lockbox.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Projections []LockboxSecretProjection `json:"projections,omitempty"`
+}
+
+// LockboxSecretProjection defines the structure of a Secret created from a subset
+// of a Lockbox's data.
+type LockboxSecretProjection struct {
+	// Name of the Secret, created in the same namespace as the Lockbox.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	LockboxSecretTemplateMetadata `json:"metadata,omitempty"`
+
+	// Type is used to facilitate programmatic handling of secret data.
+	Type corev1.SecretType `json:"type,omitempty"`
+
+	// Keys selects which unlocked data keys are included in the Secret. If
+	// empty, all data is included.
+	// +optional
+	Keys []LockboxKeyProjection `json:"keys,omitempty"`
+
+	// Immutable, if set, marks the Secret as immutable. Changes to the Secret's
+	// data are rolled out according to the immutability strategy.
+	// +optional
+	Immutable *LockboxSecretImmutability `json:"immutable,omitempty"`
+}
+
+// LockboxKeyProjection selects a data key for a projected Secret, optionally
+// renaming it.
+type LockboxKeyProjection struct {
+	// Key of the unlocked data, including any rendered templates.
+	Key string `json:"key"`
+
+	// Name of the key in the projected Secret. Defaults to Key.
+	// +optional
+	Name string `json:"name,omitempty"`
 }
 
 type LockboxSecretTemplateMetadata struct {
@@ -73,11 +176,40 @@ type LockboxSecretTemplateMetadata struct {
 	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
+// LockboxSecretImmutability defines how immutable Secrets are replaced when their
+// data changes.
+type LockboxSecretImmutability struct {
+	// Strategy used to replace the Secret when its data changes. Hash suffixes
+	// the Secret's name with a hash of the Secret's data, creating a new Secret for
+	// each change. Recreate deletes and recreates the Secret under the same name.
+	// +optional
+	// +kubebuilder:default=Hash
+	Strategy ImmutableStrategy `json:"strategy,omitempty"`
+
+	// RetentionPeriod is how long Secrets superseded under the Hash strategy are
+	// kept before being deleted, giving workloads time to roll out. Superseded
+	// Secrets are deleted immediately if unset.
+	// +optional
+	RetentionPeriod *metav1.Duration `json:"retentionPeriod,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=Hash;Recreate
+type ImmutableStrategy string
+
+const (
+	ImmutableStrategyHash     ImmutableStrategy = "Hash"
+	ImmutableStrategyRecreate ImmutableStrategy = "Recreate"
+)
+
 // LockboxStatus contains status information about a Lockbox.
 type LockboxStatus struct {
 	// List of status conditions to indicate the status of a Lockbox.
 	// +optional
 	Conditions []Condition `json:"conditions,omitempty"`
+
+	// Names of the Secrets currently created from this Lockbox.
+	// +optional
+	Secrets []string `json:"secrets,omitempty"`
 }
 
 // Condition contains condition information for a Lockbox.
@@ -112,11 +244,15 @@ type Condition struct {
 	Message string `json:"message,omitempty"`
 }
 
-// +kubebuilder:validation:Enum=Ready
+// +kubebuilder:validation:Enum=Ready;SecretDataValid
 type ConditionType string
 
 const (
 	ReadyCondition ConditionType = "Ready"
+
+	// SecretDataValidCondition reports whether the Lockbox's Secrets are usable for their
+	// types, beyond the keys the API server requires. Secrets are written regardless.
+	SecretDataValidCondition ConditionType = "SecretDataValid"
 )
 
 // +kubebuilder:validation:Enum=Error;Warning;Info
@@ -138,3 +274,68 @@ type LockboxList struct {
 
 	Items []Lockbox
 }
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Active",type=boolean,JSONPath=`.spec.active`
+// +kubebuilder:printcolumn:name="Namespace",type=string,JSONPath=`.spec.namespace`
+// +kubebuilder:printcolumn:name="Created",type=date,JSONPath=`.spec.created`
+
+// LockboxKey publishes one of the controller's public keys, so clients can discover it
+// without access to the controller's service. LockboxKeys are named by the public key's
+// fingerprint and managed by the controller. Keys derived for a namespace are labelled
+// with LockboxKeyNamespaceLabel.
+type LockboxKey struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec LockboxKeySpec `json:"spec"`
+}
+
+// LockboxKeySpec describes a controller public key.
+type LockboxKeySpec struct {
+	// PublicKey is the Curve25519 public key used to lock new Lockboxes.
+	PublicKey []byte `json:"publicKey"`
+
+	// HybridPublicKey is the hybrid public key used to lock Lockboxes with the
+	// x25519-mlkem768 algorithm.
+	// Unset for keypairs without an ML-KEM-768 key.
+	// +optional
+	HybridPublicKey []byte `json:"hybridPublicKey,omitempty"`
+
+	// Fingerprint identifies the public key, and matches the LockboxKey's name.
+	Fingerprint string `json:"fingerprint"`
+
+	// NamespaceKeys is set when the controller derives a key for each namespace from this
+	// key. New Lockboxes should then be locked for their namespace's key, published as a
+	// LockboxKey with Namespace set, rather than for this key.
+	// +optional
+	NamespaceKeys bool `json:"namespaceKeys,omitempty"`
+
+	// Namespace is set on keys derived for a namespace, which only unlock Lockboxes in
+	// that namespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Active is set on the key offered for new Lockboxes. Other keys can still unlock
+	// existing Lockboxes.
+	Active bool `json:"active"`
+
+	// Created is when the keypair was created, if known.
+	// +optional
+	Created *metav1.Time `json:"created,omitempty"`
+}
+
+// LockboxKeyNamespaceLabel is set on LockboxKeys derived for a namespace to the namespace's
+// name, so clients can select them.
+const LockboxKeyNamespaceLabel = "lockbox.k8s.cloudflare.com/namespace"
+
+// +kubebuilder:object:root=true
+
+// LockboxKeyList is a list of LockboxKeys.
+type LockboxKeyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []LockboxKey `json:"items"`
+}
@@ -5,6 +5,7 @@
 package v1
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -51,6 +52,108 @@ func (in *Lockbox) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LockboxKey) DeepCopyInto(out *LockboxKey) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LockboxKey.
+func (in *LockboxKey) DeepCopy() *LockboxKey {
+	if in == nil {
+		return nil
+	}
+	out := new(LockboxKey)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LockboxKey) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LockboxKeyList) DeepCopyInto(out *LockboxKeyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]LockboxKey, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LockboxKeyList.
+func (in *LockboxKeyList) DeepCopy() *LockboxKeyList {
+	if in == nil {
+		return nil
+	}
+	out := new(LockboxKeyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LockboxKeyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LockboxKeyProjection) DeepCopyInto(out *LockboxKeyProjection) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LockboxKeyProjection.
+func (in *LockboxKeyProjection) DeepCopy() *LockboxKeyProjection {
+	if in == nil {
+		return nil
+	}
+	out := new(LockboxKeyProjection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LockboxKeySpec) DeepCopyInto(out *LockboxKeySpec) {
+	*out = *in
+	if in.PublicKey != nil {
+		in, out := &in.PublicKey, &out.PublicKey
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.HybridPublicKey != nil {
+		in, out := &in.HybridPublicKey, &out.HybridPublicKey
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.Created != nil {
+		in, out := &in.Created, &out.Created
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LockboxKeySpec.
+func (in *LockboxKeySpec) DeepCopy() *LockboxKeySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LockboxKeySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LockboxList) DeepCopyInto(out *LockboxList) {
 	*out = *in
@@ -83,10 +186,110 @@ func (in *LockboxList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LockboxRecipient) DeepCopyInto(out *LockboxRecipient) {
+	*out = *in
+	if in.Sender != nil {
+		in, out := &in.Sender, &out.Sender
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.Peer != nil {
+		in, out := &in.Peer, &out.Peer
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.Key != nil {
+		in, out := &in.Key, &out.Key
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.Encapsulation != nil {
+		in, out := &in.Encapsulation, &out.Encapsulation
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LockboxRecipient.
+func (in *LockboxRecipient) DeepCopy() *LockboxRecipient {
+	if in == nil {
+		return nil
+	}
+	out := new(LockboxRecipient)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LockboxSecretImmutability) DeepCopyInto(out *LockboxSecretImmutability) {
+	*out = *in
+	if in.RetentionPeriod != nil {
+		in, out := &in.RetentionPeriod, &out.RetentionPeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LockboxSecretImmutability.
+func (in *LockboxSecretImmutability) DeepCopy() *LockboxSecretImmutability {
+	if in == nil {
+		return nil
+	}
+	out := new(LockboxSecretImmutability)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LockboxSecretProjection) DeepCopyInto(out *LockboxSecretProjection) {
+	*out = *in
+	in.LockboxSecretTemplateMetadata.DeepCopyInto(&out.LockboxSecretTemplateMetadata)
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = make([]LockboxKeyProjection, len(*in))
+		copy(*out, *in)
+	}
+	if in.Immutable != nil {
+		in, out := &in.Immutable, &out.Immutable
+		*out = new(LockboxSecretImmutability)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LockboxSecretProjection.
+func (in *LockboxSecretProjection) DeepCopy() *LockboxSecretProjection {
+	if in == nil {
+		return nil
+	}
+	out := new(LockboxSecretProjection)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LockboxSecretTemplate) DeepCopyInto(out *LockboxSecretTemplate) {
 	*out = *in
 	in.LockboxSecretTemplateMetadata.DeepCopyInto(&out.LockboxSecretTemplateMetadata)
+	if in.Data != nil {
+		in, out := &in.Data, &out.Data
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Immutable != nil {
+		in, out := &in.Immutable, &out.Immutable
+		*out = new(LockboxSecretImmutability)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Projections != nil {
+		in, out := &in.Projections, &out.Projections
+		*out = make([]LockboxSecretProjection, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LockboxSecretTemplate.
@@ -162,6 +365,13 @@ func (in *LockboxSpec) DeepCopyInto(out *LockboxSpec) {
 			(*out)[key] = outVal
 		}
 	}
+	if in.Recipients != nil {
+		in, out := &in.Recipients, &out.Recipients
+		*out = make([]LockboxRecipient, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.Template.DeepCopyInto(&out.Template)
 }
 
@@ -185,6 +395,11 @@ func (in *LockboxStatus) DeepCopyInto(out *LockboxStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Secrets != nil {
+		in, out := &in.Secrets, &out.Secrets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LockboxStatus.
@@ -0,0 +1,45 @@
+package flagvar
+
+import "strings"
+
+// Strings is a flag.Value for lists of strings. Values may be comma-separated, or
+// provided by repeating the flag. The first use of the flag replaces any default values.
+type Strings struct {
+	Value []string
+	set   bool
+}
+
+// Help returns a string to include in the flag's help message.
+func (s *Strings) Help() string {
+	return "comma-separated list, may be repeated"
+}
+
+// Set implements flag.Value by appending each comma-separated value.
+func (s *Strings) Set(v string) error {
+	if !s.set {
+		s.Value = nil
+		s.set = true
+	}
+
+	for _, value := range strings.Split(v, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			s.Value = append(s.Value, value)
+		}
+	}
+
+	return nil
+}
+
+// String implements flag.Value by returning the comma-separated values.
+func (s *Strings) String() string {
+	if s == nil {
+		return ""
+	}
+
+	return strings.Join(s.Value, ",")
+}
+
+// Type implements pflag.Value by noting our Value is a string slice.
+func (s *Strings) Type() string {
+	return "stringSlice"
+}
@@ -0,0 +1,63 @@
+package flagvar_test
+
+import (
+	"testing"
+
+	"github.com/cloudflare/lockbox/pkg/flagvar"
+	"gotest.tools/v3/assert"
+)
+
+func TestStringsSet(t *testing.T) {
+	type testCase struct {
+		name     string
+		initial  []string
+		inputs   []string
+		expected []string
+	}
+
+	run := func(t *testing.T, tc testCase) {
+		fv := &flagvar.Strings{Value: tc.initial}
+		for _, input := range tc.inputs {
+			assert.NilError(t, fv.Set(input))
+		}
+
+		assert.DeepEqual(t, fv.Value, tc.expected)
+	}
+
+	testCases := []testCase{
+		{
+			name:     "comma-separated",
+			inputs:   []string{"a,b, c"},
+			expected: []string{"a", "b", "c"},
+		},
+		{
+			name:     "repeated",
+			inputs:   []string{"a", "b,c"},
+			expected: []string{"a", "b", "c"},
+		},
+		{
+			name:     "replaces defaults",
+			initial:  []string{"*"},
+			inputs:   []string{"a"},
+			expected: []string{"a"},
+		},
+		{
+			name:     "ignores empty values",
+			inputs:   []string{"a,,b,"},
+			expected: []string{"a", "b"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			run(t, tc)
+		})
+	}
+}
+
+func TestStringsString(t *testing.T) {
+	var nilStrings *flagvar.Strings
+	assert.Equal(t, nilStrings.String(), "")
+	assert.Equal(t, (&flagvar.Strings{Value: []string{"a", "b"}}).String(), "a,b")
+}
@@ -0,0 +1,33 @@
+// Package importer recovers Secrets from the encrypted formats of other tools, so they
+// can be locked as Lockboxes without writing plaintext to disk.
+package importer
+
+import (
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Secret is a Secret recovered from another tool's format.
+type Secret struct {
+	corev1.Secret
+
+	// Templates holds unencrypted Go text/template strings rendered with the decrypted
+	// data, as Lockbox's spec.template.data.
+	Templates map[string]string
+
+	// Immutable reports whether the Secret should be created as immutable.
+	Immutable bool
+}
+
+// Template applies the Secret's templates and immutability to a Lockbox created from it.
+func (s *Secret) Template(lb *lockboxv1.Lockbox) {
+	if len(s.Templates) > 0 {
+		lb.Spec.Template.Data = s.Templates
+	}
+	if s.Immutable {
+		// Keep the Secret's name, as the source tool did.
+		lb.Spec.Template.Immutable = &lockboxv1.LockboxSecretImmutability{
+			Strategy: lockboxv1.ImmutableStrategyRecreate,
+		}
+	}
+}
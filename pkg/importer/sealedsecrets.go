@@ -0,0 +1,227 @@
+package importer
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// SealedSecretsNamespaceWideAnnotation marks SealedSecrets that can be renamed within
+	// their namespace.
+	SealedSecretsNamespaceWideAnnotation = "sealedsecrets.bitnami.com/namespace-wide"
+
+	// SealedSecretsClusterWideAnnotation marks SealedSecrets that can be unsealed under
+	// any name and namespace.
+	SealedSecretsClusterWideAnnotation = "sealedsecrets.bitnami.com/cluster-wide"
+)
+
+// sealedSecret is the subset of Bitnami's SealedSecret resource needed to unseal it.
+type sealedSecret struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec struct {
+		Template struct {
+			metav1.ObjectMeta `json:"metadata"`
+
+			Type      corev1.SecretType `json:"type,omitempty"`
+			Immutable *bool             `json:"immutable,omitempty"`
+			Data      map[string]string `json:"data,omitempty"`
+		} `json:"template"`
+
+		EncryptedData map[string]string `json:"encryptedData"`
+	} `json:"spec"`
+}
+
+// SealedSecrets decrypts Bitnami SealedSecrets with the SealedSecrets controller's private
+// keys.
+type SealedSecrets struct {
+	keys []*rsa.PrivateKey
+}
+
+// NewSealedSecrets returns a SealedSecrets importer using the provided private keys. The
+// controller rotates its keys, so all keys in use when the SealedSecrets were sealed are
+// needed.
+func NewSealedSecrets(keys ...*rsa.PrivateKey) *SealedSecrets {
+	return &SealedSecrets{keys: keys}
+}
+
+// ParseSealedSecretsKeys reads RSA private keys from PEM, or from the tls.key entry of the
+// SealedSecrets controller's key Secrets as YAML or JSON, either alone or in a List.
+func ParseSealedSecretsKeys(b []byte) ([]*rsa.PrivateKey, error) {
+	if keys, err := parsePEMKeys(b); err != nil || len(keys) > 0 {
+		return keys, err
+	}
+
+	var list struct {
+		Kind  string          `json:"kind"`
+		Items []corev1.Secret `json:"items"`
+	}
+	if err := yaml.Unmarshal(b, &list); err != nil {
+		return nil, err
+	}
+	if list.Kind != "List" && list.Kind != "SecretList" {
+		var secret corev1.Secret
+		if err := yaml.Unmarshal(b, &secret); err != nil {
+			return nil, err
+		}
+		list.Items = []corev1.Secret{secret}
+	}
+
+	var keys []*rsa.PrivateKey
+	for _, secret := range list.Items {
+		k, err := parsePEMKeys(secret.Data[corev1.TLSPrivateKeyKey])
+		if err != nil {
+			return nil, fmt.Errorf("secret %q: %w", secret.Name, err)
+		}
+		keys = append(keys, k...)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no private keys found")
+	}
+
+	return keys, nil
+}
+
+func parsePEMKeys(b []byte) ([]*rsa.PrivateKey, error) {
+	var keys []*rsa.PrivateKey
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			return keys, nil
+		}
+
+		switch block.Type {
+		case "RSA PRIVATE KEY":
+			k, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, k)
+		case "PRIVATE KEY":
+			k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			rsaKey, ok := k.(*rsa.PrivateKey)
+			if !ok {
+				return nil, fmt.Errorf("unsupported private key type %T", k)
+			}
+			keys = append(keys, rsaKey)
+		}
+	}
+}
+
+// Unseal decrypts a SealedSecret manifest in YAML or JSON, returning the Secret it
+// describes. The namespace, if set, overrides the SealedSecret's namespace, and is only
+// permitted to differ for SealedSecrets sealed with the cluster-wide scope.
+func (s *SealedSecrets) Unseal(manifest []byte, namespace string) (*Secret, error) {
+	var ss sealedSecret
+	if err := yaml.Unmarshal(manifest, &ss); err != nil {
+		return nil, err
+	}
+	if ss.Kind != "SealedSecret" {
+		return nil, fmt.Errorf("unexpected kind %q, should be SealedSecret", ss.Kind)
+	}
+
+	clusterWide := ss.Annotations[SealedSecretsClusterWideAnnotation] == "true"
+	if namespace == "" {
+		namespace = ss.Namespace
+	}
+	if namespace != ss.Namespace && !clusterWide {
+		return nil, fmt.Errorf("sealedsecret %q was sealed for namespace %q", ss.Name, ss.Namespace)
+	}
+
+	label := sealedSecretsLabel(&ss)
+	data := make(map[string][]byte, len(ss.Spec.EncryptedData))
+	for key, value := range ss.Spec.EncryptedData {
+		ciphertext, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", key, err)
+		}
+
+		plaintext, err := s.decrypt(ciphertext, label)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", key, err)
+		}
+		data[key] = plaintext
+	}
+
+	secret := &Secret{
+		Secret: corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        ss.Name,
+				Namespace:   namespace,
+				Labels:      ss.Spec.Template.Labels,
+				Annotations: ss.Spec.Template.Annotations,
+			},
+			Type: ss.Spec.Template.Type,
+			Data: data,
+		},
+		Templates: ss.Spec.Template.Data,
+		Immutable: ss.Spec.Template.Immutable != nil && *ss.Spec.Template.Immutable,
+	}
+	if ss.Spec.Template.Name != "" {
+		secret.Name = ss.Spec.Template.Name
+	}
+
+	return secret, nil
+}
+
+// sealedSecretsLabel returns the OAEP label binding a SealedSecret's values to its scope.
+func sealedSecretsLabel(ss *sealedSecret) []byte {
+	switch {
+	case ss.Annotations[SealedSecretsClusterWideAnnotation] == "true":
+		return nil
+	case ss.Annotations[SealedSecretsNamespaceWideAnnotation] == "true":
+		return []byte(ss.Namespace)
+	default:
+		return []byte(ss.Namespace + "/" + ss.Name)
+	}
+}
+
+// decrypt reverses SealedSecrets' hybrid encryption: a two byte length, an AES-256 session
+// key encrypted with RSA-OAEP, and the value encrypted with AES-GCM using a zero nonce.
+func (s *SealedSecrets) decrypt(ciphertext, label []byte) ([]byte, error) {
+	if len(ciphertext) < 2 {
+		return nil, errors.New("ciphertext too short")
+	}
+	rsaLen := int(binary.BigEndian.Uint16(ciphertext))
+	if len(ciphertext) < 2+rsaLen {
+		return nil, errors.New("ciphertext too short")
+	}
+	rsaCiphertext, aesCiphertext := ciphertext[2:2+rsaLen], ciphertext[2+rsaLen:]
+
+	for _, key := range s.keys {
+		sessionKey, err := rsa.DecryptOAEP(sha256.New(), nil, key, rsaCiphertext, label)
+		if err != nil {
+			continue
+		}
+
+		block, err := aes.NewCipher(sessionKey)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+
+		return aead.Open(nil, make([]byte, aead.NonceSize()), aesCiphertext, nil)
+	}
+
+	return nil, errors.New("no private key could decrypt the value")
+}
@@ -0,0 +1,141 @@
+package importer_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"testing"
+
+	"github.com/cloudflare/lockbox/pkg/importer"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestSealedSecrets(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NilError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NilError(t, err)
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	otherPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(otherKey)})
+
+	keys, err := importer.ParseSealedSecretsKeys(append(otherPEM, keyPEM...))
+	assert.NilError(t, err)
+	assert.Equal(t, len(keys), 2)
+
+	keySecret := fmt.Sprintf(`apiVersion: v1
+kind: Secret
+metadata:
+  name: sealed-secrets-key
+type: kubernetes.io/tls
+data:
+  tls.key: %s
+`, base64.StdEncoding.EncodeToString(keyPEM))
+	keys, err = importer.ParseSealedSecretsKeys([]byte(keySecret))
+	assert.NilError(t, err)
+	assert.Equal(t, len(keys), 1)
+
+	ss := importer.NewSealedSecrets(otherKey, key)
+
+	type testCase struct {
+		annotations string
+		label       string
+		namespace   string
+		wantErr     string
+	}
+
+	run := func(t *testing.T, tc testCase) {
+		manifest := fmt.Sprintf(`apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: mysecret
+  namespace: default
+  annotations: {%s}
+spec:
+  encryptedData:
+    password: %s
+  template:
+    metadata:
+      labels:
+        app: example
+    type: Opaque
+    immutable: true
+    data:
+      dsn: "postgres://app:{{ .password }}@db"
+`, tc.annotations, sealedSecretsEncrypt(t, &key.PublicKey, []byte("hunter2"), []byte(tc.label)))
+
+		secret, err := ss.Unseal([]byte(manifest), tc.namespace)
+		if tc.wantErr != "" {
+			assert.ErrorContains(t, err, tc.wantErr)
+			return
+		}
+		assert.NilError(t, err)
+
+		assert.Equal(t, secret.Name, "mysecret")
+		assert.DeepEqual(t, secret.Labels, map[string]string{"app": "example"})
+		assert.Equal(t, secret.Type, corev1.SecretTypeOpaque)
+		assert.DeepEqual(t, secret.Data, map[string][]byte{"password": []byte("hunter2")})
+		assert.DeepEqual(t, secret.Templates, map[string]string{"dsn": "postgres://app:{{ .password }}@db"})
+		assert.Assert(t, secret.Immutable)
+	}
+
+	testCases := map[string]testCase{
+		"strict": {
+			label: "default/mysecret",
+		},
+		"strict wrong label": {
+			label:   "default/other",
+			wantErr: "no private key could decrypt the value",
+		},
+		"strict other namespace": {
+			label:     "default/mysecret",
+			namespace: "other",
+			wantErr:   `was sealed for namespace "default"`,
+		},
+		"namespace-wide": {
+			annotations: `sealedsecrets.bitnami.com/namespace-wide: "true"`,
+			label:       "default",
+		},
+		"cluster-wide": {
+			annotations: `sealedsecrets.bitnami.com/cluster-wide: "true"`,
+			label:       "",
+			namespace:   "other",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			run(t, tc)
+		})
+	}
+}
+
+// sealedSecretsEncrypt encrypts a value as kubeseal does, returning it base64 encoded.
+func sealedSecretsEncrypt(t *testing.T, pub *rsa.PublicKey, plaintext, label []byte) string {
+	t.Helper()
+
+	sessionKey := make([]byte, 32)
+	_, err := rand.Read(sessionKey)
+	assert.NilError(t, err)
+
+	rsaCiphertext, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, sessionKey, label)
+	assert.NilError(t, err)
+
+	block, err := aes.NewCipher(sessionKey)
+	assert.NilError(t, err)
+	aead, err := cipher.NewGCM(block)
+	assert.NilError(t, err)
+
+	ciphertext := binary.BigEndian.AppendUint16(nil, uint16(len(rsaCiphertext)))
+	ciphertext = append(ciphertext, rsaCiphertext...)
+	ciphertext = aead.Seal(ciphertext, make([]byte, aead.NonceSize()), plaintext, nil)
+	return base64.StdEncoding.EncodeToString(ciphertext)
+}
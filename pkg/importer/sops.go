@@ -0,0 +1,296 @@
+package importer
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// sopsValueRE matches a value encrypted by SOPS.
+var sopsValueRE = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.*),iv:(.*),tag:(.*),type:(.*)\]$`)
+
+// sopsMetadata is the subset of the "sops" key of an encrypted document needed to decrypt
+// it with age.
+type sopsMetadata struct {
+	Age []struct {
+		Recipient string `yaml:"recipient"`
+		Enc       string `yaml:"enc"`
+	} `yaml:"age"`
+	KeyGroups        []yaml.Node `yaml:"key_groups"`
+	ShamirThreshold  int         `yaml:"shamir_threshold"`
+	LastModified     string      `yaml:"lastmodified"`
+	MAC              string      `yaml:"mac"`
+	MACOnlyEncrypted bool        `yaml:"mac_only_encrypted"`
+}
+
+// SOPS decrypts Secret manifests encrypted by SOPS with age recipients.
+type SOPS struct {
+	identities []age.Identity
+}
+
+// NewSOPS returns a SOPS importer using the provided age identities.
+func NewSOPS(identities ...age.Identity) *SOPS {
+	return &SOPS{identities: identities}
+}
+
+// Unseal decrypts a SOPS encrypted Secret manifest in YAML or JSON, verifying its MAC.
+// The namespace, if set, overrides the Secret's namespace.
+func (s *SOPS) Unseal(manifest []byte, namespace string) (*Secret, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(manifest, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("document isn't a mapping")
+	}
+	root := doc.Content[0]
+
+	var meta *sopsMetadata
+	for i := 0; i < len(root.Content); i += 2 {
+		if root.Content[i].Value != "sops" {
+			continue
+		}
+
+		meta = &sopsMetadata{}
+		if err := root.Content[i+1].Decode(meta); err != nil {
+			return nil, fmt.Errorf("invalid sops metadata: %w", err)
+		}
+		root.Content = append(root.Content[:i], root.Content[i+2:]...)
+		break
+	}
+	if meta == nil {
+		return nil, errors.New("document isn't encrypted by sops")
+	}
+
+	dataKey, err := s.dataKey(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &sopsWalker{
+		dataKey:       dataKey,
+		hash:          sha512.New(),
+		onlyEncrypted: meta.MACOnlyEncrypted,
+	}
+	if err := w.walk(root, nil); err != nil {
+		return nil, err
+	}
+	if err := w.verify(meta); err != nil {
+		return nil, err
+	}
+
+	b, err := yaml.Marshal(root)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &Secret{}
+	if err := sigsyaml.Unmarshal(b, &secret.Secret); err != nil {
+		return nil, err
+	}
+	if secret.Kind != "Secret" {
+		return nil, fmt.Errorf("unexpected kind %q, should be Secret", secret.Kind)
+	}
+	if namespace != "" {
+		secret.Namespace = namespace
+	}
+	if secret.Secret.Immutable != nil {
+		secret.Immutable, secret.Secret.Immutable = *secret.Secret.Immutable, nil
+	}
+	if secret.Type == "" {
+		secret.Type = corev1.SecretTypeOpaque
+	}
+
+	return secret, nil
+}
+
+// dataKey decrypts the SOPS data key with one of the age identities.
+func (s *SOPS) dataKey(meta *sopsMetadata) ([]byte, error) {
+	if len(meta.KeyGroups) > 0 || meta.ShamirThreshold > 0 {
+		return nil, errors.New("sops key groups are not supported, only age recipients")
+	}
+	if len(meta.Age) == 0 {
+		return nil, errors.New("document has no sops age recipients")
+	}
+
+	for _, recipient := range meta.Age {
+		r, err := age.Decrypt(armor.NewReader(strings.NewReader(recipient.Enc)), s.identities...)
+		if errors.As(err, new(*age.NoIdentityMatchError)) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("age recipient %s: %w", recipient.Recipient, err)
+		}
+
+		return io.ReadAll(r)
+	}
+
+	return nil, errors.New("no age identity matched the document's recipients")
+}
+
+// sopsWalker decrypts the values of a document in place, hashing them in the order SOPS
+// computes its MAC.
+type sopsWalker struct {
+	dataKey       []byte
+	hash          hash.Hash
+	onlyEncrypted bool
+}
+
+func (w *sopsWalker) walk(n *yaml.Node, path []string) error {
+	if strings.Contains(n.HeadComment+n.LineComment+n.FootComment, "ENC[") {
+		return errors.New("encrypted comments are not supported")
+	}
+
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i < len(n.Content); i += 2 {
+			key := n.Content[i]
+			if err := w.walk(n.Content[i+1], append(path[:len(path):len(path)], key.Value)); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range n.Content {
+			if err := w.walk(item, path); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		return w.leaf(n, path)
+	case yaml.AliasNode:
+		return errors.New("yaml aliases are not supported")
+	}
+
+	return nil
+}
+
+func (w *sopsWalker) leaf(n *yaml.Node, path []string) error {
+	if n.Tag == "!!null" {
+		return nil
+	}
+
+	m := sopsValueRE.FindStringSubmatch(n.Value)
+	if m == nil {
+		if !w.onlyEncrypted {
+			b, err := sopsBytes(n)
+			if err != nil {
+				return err
+			}
+			w.hash.Write(b)
+		}
+		return nil
+	}
+
+	plaintext, err := w.decrypt(n.Value, strings.Join(path, ":")+":")
+	if err != nil {
+		return fmt.Errorf("%s: %w", strings.Join(path, "."), err)
+	}
+	w.hash.Write(plaintext)
+
+	n.Value, n.Style = string(plaintext), 0
+	switch m[4] {
+	case "str", "bytes":
+		n.Tag = "!!str"
+	case "int":
+		n.Tag = "!!int"
+	case "float":
+		n.Tag = "!!float"
+	case "bool":
+		n.Tag = "!!bool"
+	default:
+		return fmt.Errorf("%s: unsupported sops value type %q", strings.Join(path, "."), m[4])
+	}
+	return nil
+}
+
+// decrypt decrypts a SOPS value with AES-GCM, authenticating the additional data.
+func (w *sopsWalker) decrypt(value, additionalData string) ([]byte, error) {
+	m := sopsValueRE.FindStringSubmatch(value)
+	if m == nil {
+		return nil, errors.New("value isn't encrypted by sops")
+	}
+
+	var parts [3][]byte
+	for i := range parts {
+		var err error
+		parts[i], err = base64.StdEncoding.DecodeString(m[i+1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	data, iv, tag := parts[0], parts[1], parts[2]
+
+	block, err := aes.NewCipher(w.dataKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Open(nil, iv, append(data, tag...), []byte(additionalData))
+}
+
+// verify checks the document's MAC against the decrypted values.
+func (w *sopsWalker) verify(meta *sopsMetadata) error {
+	lastModified, err := time.Parse(time.RFC3339, meta.LastModified)
+	if err != nil {
+		return fmt.Errorf("invalid sops lastmodified: %w", err)
+	}
+
+	mac, err := w.decrypt(meta.MAC, lastModified.Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("unable to decrypt sops mac: %w", err)
+	}
+
+	if computed := fmt.Sprintf("%X", w.hash.Sum(nil)); !bytes.Equal(mac, []byte(computed)) {
+		return errors.New("sops mac mismatch, the document has been modified")
+	}
+	return nil
+}
+
+// sopsBytes returns the bytes SOPS hashes for an unencrypted value.
+func sopsBytes(n *yaml.Node) ([]byte, error) {
+	switch n.Tag {
+	case "!!int":
+		i, err := strconv.Atoi(n.Value)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strconv.Itoa(i)), nil
+	case "!!float":
+		f, err := strconv.ParseFloat(n.Value, 64)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strconv.FormatFloat(f, 'f', -1, 64)), nil
+	case "!!bool":
+		var b bool
+		if err := n.Decode(&b); err != nil {
+			return nil, err
+		}
+		if b {
+			return []byte("True"), nil
+		}
+		return []byte("False"), nil
+	default:
+		return []byte(n.Value), nil
+	}
+}
@@ -0,0 +1,127 @@
+package importer_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/cloudflare/lockbox/pkg/importer"
+	"gotest.tools/v3/assert"
+)
+
+const sopsLastModified = "2024-05-01T12:00:00Z"
+
+func TestSOPS(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	assert.NilError(t, err)
+	other, err := age.GenerateX25519Identity()
+	assert.NilError(t, err)
+
+	dataKey := make([]byte, 32)
+	_, err = rand.Read(dataKey)
+	assert.NilError(t, err)
+
+	// Encrypt as sops does with encrypted_regex: ^(data|stringData)$.
+	password := sopsEncrypt(t, dataKey, "hunter2", "stringData:password:")
+	token := sopsEncrypt(t, dataKey, "dG9rZW4=", "data:token:")
+	h := sha512.New()
+	for _, v := range []string{"v1", "Secret", "mysecret", "default", "example", "hunter2", "dG9rZW4=", "Opaque"} {
+		h.Write([]byte(v))
+	}
+	mac := sopsEncrypt(t, dataKey, fmt.Sprintf("%X", h.Sum(nil)), sopsLastModified)
+
+	document := func(recipient age.Recipient, extra string) []byte {
+		buf := &bytes.Buffer{}
+		aw := armor.NewWriter(buf)
+		w, err := age.Encrypt(aw, recipient)
+		assert.NilError(t, err)
+		_, err = w.Write(dataKey)
+		assert.NilError(t, err)
+		assert.NilError(t, w.Close())
+		assert.NilError(t, aw.Close())
+
+		return []byte(fmt.Sprintf(`apiVersion: v1
+kind: Secret
+metadata:
+  name: mysecret
+  namespace: default
+  labels:
+    app: example
+stringData:
+  password: %s
+data:
+  token: %s
+type: Opaque
+sops:
+  age:
+  - recipient: %s
+    enc: |
+%s
+  lastmodified: "%s"
+  mac: %s
+  encrypted_regex: ^(data|stringData)$
+  version: 3.8.1
+%s`, password, token, recipient, indent(buf.String(), "      "), sopsLastModified, mac, extra))
+	}
+
+	s := importer.NewSOPS(identity)
+
+	t.Run("decrypt", func(t *testing.T) {
+		secret, err := s.Unseal(document(identity.Recipient(), ""), "")
+		assert.NilError(t, err)
+
+		assert.Equal(t, secret.Name, "mysecret")
+		assert.Equal(t, secret.Namespace, "default")
+		assert.DeepEqual(t, secret.Labels, map[string]string{"app": "example"})
+		assert.DeepEqual(t, secret.StringData, map[string]string{"password": "hunter2"})
+		assert.DeepEqual(t, secret.Data, map[string][]byte{"token": []byte("token")})
+	})
+
+	t.Run("wrong identity", func(t *testing.T) {
+		_, err := s.Unseal(document(other.Recipient(), ""), "")
+		assert.ErrorContains(t, err, "no age identity matched")
+	})
+
+	t.Run("modified", func(t *testing.T) {
+		doc := bytes.Replace(document(identity.Recipient(), ""), []byte("app: example"), []byte("app: other"), 1)
+		_, err := s.Unseal(doc, "")
+		assert.ErrorContains(t, err, "sops mac mismatch")
+	})
+
+	t.Run("key groups", func(t *testing.T) {
+		_, err := s.Unseal(document(identity.Recipient(), "  shamir_threshold: 2\n"), "")
+		assert.ErrorContains(t, err, "key groups are not supported")
+	})
+}
+
+// sopsEncrypt encrypts a string value as sops does.
+func sopsEncrypt(t *testing.T, key []byte, value, additionalData string) string {
+	t.Helper()
+
+	iv := make([]byte, 32)
+	_, err := rand.Read(iv)
+	assert.NilError(t, err)
+
+	block, err := aes.NewCipher(key)
+	assert.NilError(t, err)
+	aead, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	assert.NilError(t, err)
+
+	sealed := aead.Seal(nil, iv, []byte(value), []byte(additionalData))
+	data, tag := sealed[:len(sealed)-aead.Overhead()], sealed[len(sealed)-aead.Overhead():]
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:str]",
+		base64.StdEncoding.EncodeToString(data), base64.StdEncoding.EncodeToString(iv), base64.StdEncoding.EncodeToString(tag))
+}
+
+func indent(s, prefix string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	return prefix + strings.Join(lines, "\n"+prefix)
+}
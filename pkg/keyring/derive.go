@@ -0,0 +1,173 @@
+package keyring
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+
+	"github.com/kevinburke/nacl"
+	"github.com/kevinburke/nacl/scalarmult"
+	"golang.org/x/crypto/hkdf"
+)
+
+// derivationPoint is the public point combined with a keypair's private key to seed
+// namespace key derivation. It's a hash output, so its discrete logarithm is unknown and
+// the seed can only be computed with the private key.
+var derivationPoint = func() nacl.Key {
+	sum := sha256.Sum256([]byte("lockbox.k8s.cloudflare.com/namespace-key-derivation"))
+	return &sum
+}()
+
+// ErrNoNamespaceKeys is returned when namespace keys can't be derived from a keypair, as its
+// private key isn't held in memory.
+var ErrNoNamespaceKeys = errors.New("namespace keys are only derived from keys held in memory")
+
+// DeriveNamespaceKey derives the keypair for a namespace from the private key, using HKDF
+// keyed by the private key's shared key with a fixed point. Lockboxes sealed for a derived
+// public key can only be unlocked in that namespace.
+//
+// The seed is as sensitive as the private key itself, since it unlocks every namespace, so
+// it's only computed for keys already held in memory. For other providers, such as a
+// PKCS#11 token, ErrNoNamespaceKeys is returned rather than copying a master secret out of
+// the token.
+func DeriveNamespaceKey(provider KeyProvider, namespace string) (pub, pri nacl.Key, err error) {
+	seed, err := namespaceSeed(provider)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pub, pri = deriveFromSeed(seed, namespace)
+	return pub, pri, nil
+}
+
+func deriveFromSeed(seed nacl.Key, namespace string) (pub, pri nacl.Key) {
+	pri = new([nacl.KeySize]byte)
+	r := hkdf.New(sha256.New, seed[:], nil, []byte("lockbox namespace key: "+namespace))
+	if _, err := io.ReadFull(r, pri[:]); err != nil {
+		// HKDF can produce up to 255 hashes of output, far more than a key.
+		panic(err)
+	}
+
+	return scalarmult.Base(pri), pri
+}
+
+// deriveMLKEMSeed derives the seed of a namespace's ML-KEM-768 decapsulation key from the
+// keypair's own seed.
+func deriveMLKEMSeed(mlkemSeed []byte, namespace string) []byte {
+	seed := make([]byte, len(mlkemSeed))
+	r := hkdf.New(sha256.New, mlkemSeed, nil, []byte("lockbox namespace ml-kem-768 seed: "+namespace))
+	if _, err := io.ReadFull(r, seed); err != nil {
+		// HKDF can produce up to 255 hashes of output, far more than a seed.
+		panic(err)
+	}
+
+	return seed
+}
+
+// derivesNamespaceKeys reports whether namespace keys are derived from the keypair.
+func (k *Keyring) derivesNamespaceKeys(pair KeyPair) bool {
+	_, err := k.derivationSeed(pair)
+	return !errors.Is(err, ErrNoNamespaceKeys)
+}
+
+// namespaceProvider returns the public key and provider of the keypair derived for the
+// namespace from the keypair, including its ML-KEM-768 decapsulation key if the keypair
+// has one.
+func (k *Keyring) namespaceProvider(pair KeyPair, namespace string) (nacl.Key, KeyProvider, error) {
+	seed, err := k.derivationSeed(pair)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pub, pri := deriveFromSeed(seed, namespace)
+	// derivationSeed only succeeds for static keys.
+	var mlkemSeed []byte
+	if s := pair.Private.(staticKey).mlkemSeed; s != nil {
+		mlkemSeed = deriveMLKEMSeed(*s, namespace)
+	}
+	return pub, StaticHybridKey(pri, mlkemSeed), nil
+}
+
+// NamespacePublicKey returns the public key derived for the namespace from the active
+// keypair, or nil if the Keyring is empty. It returns ErrNoNamespaceKeys if the active
+// keypair's private key isn't held in memory.
+func (k *Keyring) NamespacePublicKey(namespace string) (nacl.Key, error) {
+	pairs := k.KeyPairs()
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+
+	return k.namespacePublicKey(pairs[0], namespace)
+}
+
+// namespacePublicKey returns the public key derived for the namespace from the keypair.
+func (k *Keyring) namespacePublicKey(pair KeyPair, namespace string) (nacl.Key, error) {
+	seed, err := k.derivationSeed(pair)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, _ := deriveFromSeed(seed, namespace)
+	return pub, nil
+}
+
+// NamespacePrivateKey returns the provider of the private key matching the provided public
+// key, which may belong to a keypair or be derived from one for the namespace.
+func (k *Keyring) NamespacePrivateKey(pub nacl.Key, namespace string) (KeyProvider, bool, error) {
+	if provider, ok := k.PrivateKey(pub); ok {
+		return provider, true, nil
+	}
+
+	for _, pair := range k.KeyPairs() {
+		derivedPub, provider, err := k.namespaceProvider(pair, namespace)
+		if errors.Is(err, ErrNoNamespaceKeys) {
+			continue
+		} else if err != nil {
+			return nil, false, err
+		}
+
+		if nacl.Verify32(derivedPub, pub) {
+			return provider, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+// derivationSeed returns the keypair's namespace derivation seed, computing it at most once
+// per keypair while the Keyring holds it.
+func (k *Keyring) derivationSeed(pair KeyPair) (nacl.Key, error) {
+	id := hex.EncodeToString(pair.Public[:])
+	k.mu.RLock()
+	seed, ok := k.seeds[id]
+	k.mu.RUnlock()
+	if ok {
+		return seed, nil
+	}
+
+	seed, err := namespaceSeed(pair.Private)
+	if err != nil {
+		return nil, err
+	}
+
+	// The keypair may have been replaced while the seed was computed.
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.holds(id) {
+		if k.seeds == nil {
+			k.seeds = map[string]nacl.Key{}
+		}
+		k.seeds[id] = seed
+	}
+	return seed, nil
+}
+
+// namespaceSeed computes the namespace derivation seed of a private key held in memory.
+func namespaceSeed(provider KeyProvider) (nacl.Key, error) {
+	if _, ok := provider.(staticKey); !ok {
+		return nil, ErrNoNamespaceKeys
+	}
+
+	return provider.SharedKey(derivationPoint)
+}
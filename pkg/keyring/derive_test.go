@@ -0,0 +1,124 @@
+package keyring_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"github.com/cloudflare/lockbox/pkg/keyring"
+	"github.com/kevinburke/nacl"
+	"gotest.tools/v3/assert"
+)
+
+func TestDeriveNamespaceKey(t *testing.T) {
+	pair := generateKeyPair(t, time.Time{})
+
+	fooPub, fooPri, err := keyring.DeriveNamespaceKey(pair.Private, "foo")
+	assert.NilError(t, err)
+	barPub, _, err := keyring.DeriveNamespaceKey(pair.Private, "bar")
+	assert.NilError(t, err)
+	againPub, againPri, err := keyring.DeriveNamespaceKey(pair.Private, "foo")
+	assert.NilError(t, err)
+
+	assert.Assert(t, nacl.Verify32(fooPub, againPub))
+	assert.Assert(t, nacl.Verify32(fooPri, againPri))
+	assert.Assert(t, !nacl.Verify32(fooPub, barPub))
+	assert.Assert(t, !nacl.Verify32(fooPub, pair.Public))
+
+	other := generateKeyPair(t, time.Time{})
+	otherPub, _, err := keyring.DeriveNamespaceKey(other.Private, "foo")
+	assert.NilError(t, err)
+	assert.Assert(t, !nacl.Verify32(fooPub, otherPub))
+}
+
+func TestKeyring_NamespacePrivateKey(t *testing.T) {
+	older := generateKeyPair(t, time.Unix(1e9, 0))
+	newer := generateKeyPair(t, time.Unix(2e9, 0))
+	k := keyring.New(older.KeyPair, newer.KeyPair)
+
+	nsPub, err := k.NamespacePublicKey("foo")
+	assert.NilError(t, err)
+	expectedPub, _, err := keyring.DeriveNamespaceKey(newer.Private, "foo")
+	assert.NilError(t, err)
+	assert.Assert(t, nacl.Verify32(nsPub, expectedPub))
+
+	_, ok, err := k.NamespacePrivateKey(nsPub, "foo")
+	assert.NilError(t, err)
+	assert.Assert(t, ok)
+
+	_, ok, err = k.NamespacePrivateKey(nsPub, "bar")
+	assert.NilError(t, err)
+	assert.Assert(t, !ok, "namespace key must not match other namespaces")
+
+	olderPub, _, err := keyring.DeriveNamespaceKey(older.Private, "foo")
+	assert.NilError(t, err)
+	_, ok, err = k.NamespacePrivateKey(olderPub, "foo")
+	assert.NilError(t, err)
+	assert.Assert(t, ok, "namespace keys of superseded keypairs must still match")
+
+	provider, ok, err := k.NamespacePrivateKey(older.Public, "foo")
+	assert.NilError(t, err)
+	assert.Assert(t, ok)
+	assert.Equal(t, provider, older.Private)
+}
+
+func TestKeyring_NamespaceHybridPublicKey(t *testing.T) {
+	pair := generateKeyPair(t, time.Time{})
+	k := keyring.New(pair.KeyPair)
+
+	hybrid, err := k.HybridPublicKey("")
+	assert.NilError(t, err)
+	fooHybrid, err := k.HybridPublicKey("foo")
+	assert.NilError(t, err)
+	barHybrid, err := k.HybridPublicKey("bar")
+	assert.NilError(t, err)
+
+	_, kem, err := lockboxv1.ParseHybridPublicKey(hybrid)
+	assert.NilError(t, err)
+	fooPub, fooKEM, err := lockboxv1.ParseHybridPublicKey(fooHybrid)
+	assert.NilError(t, err)
+	_, barKEM, err := lockboxv1.ParseHybridPublicKey(barHybrid)
+	assert.NilError(t, err)
+	assert.Assert(t, !bytes.Equal(fooKEM.Bytes(), kem.Bytes()))
+	assert.Assert(t, !bytes.Equal(fooKEM.Bytes(), barKEM.Bytes()))
+
+	provider, ok, err := k.NamespacePrivateKey(fooPub, "foo")
+	assert.NilError(t, err)
+	assert.Assert(t, ok)
+	providerHybrid, err := lockboxv1.HybridPublicKey(fooPub, provider)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, providerHybrid, fooHybrid)
+
+	// Keypairs without an ML-KEM-768 seed have no hybrid keys.
+	k = keyring.New(keyring.KeyPair{Public: pair.Public, Private: keyring.StaticKey(pair.private)})
+	_, err = k.HybridPublicKey("foo")
+	assert.ErrorIs(t, err, lockboxv1.ErrNoDecapsulationKey)
+}
+
+// externalKey is a KeyProvider whose private key isn't held in memory.
+type externalKey struct {
+	keyring.KeyProvider
+}
+
+func TestKeyring_NamespaceKeysExternalKey(t *testing.T) {
+	pair := generateKeyPair(t, time.Time{})
+	pair.KeyPair.Private = externalKey{pair.Private}
+	k := keyring.New(pair.KeyPair)
+
+	_, _, err := keyring.DeriveNamespaceKey(pair.KeyPair.Private, "foo")
+	assert.ErrorIs(t, err, keyring.ErrNoNamespaceKeys)
+
+	_, err = k.NamespacePublicKey("foo")
+	assert.ErrorIs(t, err, keyring.ErrNoNamespaceKeys)
+
+	provider, ok, err := k.NamespacePrivateKey(pair.Public, "foo")
+	assert.NilError(t, err)
+	assert.Assert(t, ok, "keypair's own key must still match")
+	assert.Equal(t, provider, pair.KeyPair.Private)
+
+	other := generateKeyPair(t, time.Time{})
+	_, ok, err = k.NamespacePrivateKey(other.Public, "foo")
+	assert.NilError(t, err)
+	assert.Assert(t, !ok)
+}
@@ -0,0 +1,154 @@
+package keyring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/kevinburke/nacl"
+	"github.com/kevinburke/nacl/scalarmult"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// dataDir is the symlink atomically swapped by the kubelet when updating Secret and
+// ConfigMap volumes.
+const dataDir = "..data"
+
+// Unwrapper decrypts private keys wrapped by a key management service.
+type Unwrapper interface {
+	Unwrap(ctx context.Context, wrapped string) (nacl.Key, error)
+}
+
+// FileSourceOption allows for functional options to modify the FileSource
+type FileSourceOption func(f *FileSource)
+
+// WithUnwrapper sets the Unwrapper used to decrypt the keypair file's private key. The file
+// is then read by WrappedKeyPairFromYAMLOrJSON.
+func WithUnwrapper(u Unwrapper) FileSourceOption {
+	return func(f *FileSource) {
+		f.unwrapper = u
+	}
+}
+
+// FileSource loads a keypair into a Keyring from a YAML or JSON file, as read by
+// KeyPairFromYAMLOrJSON.
+type FileSource struct {
+	keyring   *Keyring
+	path      string
+	unwrapper Unwrapper
+}
+
+// NewFileSource creates a FileSource for the keypair file at path.
+func NewFileSource(keyring *Keyring, path string, options ...FileSourceOption) *FileSource {
+	f := &FileSource{
+		keyring: keyring,
+		path:    path,
+	}
+
+	for _, opt := range options {
+		opt(f)
+	}
+
+	return f
+}
+
+// Load replaces the Keyring's keypairs with the keypair in the file. If the file can't be
+// read, the Keyring is left unchanged.
+func (f *FileSource) Load(ctx context.Context) error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var pub, pri nacl.Key
+	var mlkemSeed []byte
+	if f.unwrapper == nil {
+		pub, pri, mlkemSeed, err = KeyPairFromYAMLOrJSON(file)
+		if err != nil {
+			return err
+		}
+	} else {
+		var wrapped string
+		pub, wrapped, err = WrappedKeyPairFromYAMLOrJSON(file)
+		if err != nil {
+			return err
+		}
+
+		pri, err = f.unwrapper.Unwrap(ctx, wrapped)
+		if err != nil {
+			return fmt.Errorf("unable to unwrap private key: %w", err)
+		}
+		if !nacl.Verify32(scalarmult.Base(pri), pub) {
+			return errors.New("unwrapped private key does not match public key")
+		}
+	}
+
+	f.keyring.Replace(KeyPair{Public: pub, Private: StaticHybridKey(pri, mlkemSeed)})
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every replica watches its
+// own keypair file.
+func (f *FileSource) NeedLeaderElection() bool {
+	return false
+}
+
+// Start implements manager.Runnable by reloading the keypair whenever the file changes, until
+// the context is cancelled. The file's directory is watched, rather than the file itself, so
+// that files replaced by renaming or by swapping a Kubernetes volume's symlinks are reloaded.
+func (f *FileSource) Start(ctx context.Context) error {
+	log := logf.FromContext(ctx).WithName("keyring").WithValues("path", f.path)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(filepath.Dir(f.path)); err != nil {
+		return err
+	}
+
+	// Reload once the watch is established, in case the file changed after the initial load.
+	if err := f.Load(ctx); err != nil {
+		log.Error(err, "unable to reload keypair")
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Error(err, "keypair watch error")
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !f.affects(ev) {
+				continue
+			}
+
+			if err := f.Load(ctx); err != nil {
+				log.Error(err, "unable to reload keypair")
+				continue
+			}
+			log.Info("reloaded keypair")
+		}
+	}
+}
+
+// affects reports whether the filesystem event may have changed the keypair file's contents.
+func (f *FileSource) affects(ev fsnotify.Event) bool {
+	if !ev.Has(fsnotify.Create) && !ev.Has(fsnotify.Write) {
+		return false
+	}
+
+	return filepath.Clean(ev.Name) == filepath.Clean(f.path) || filepath.Base(ev.Name) == dataDir
+}
@@ -0,0 +1,114 @@
+package keyring_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/lockbox/pkg/keyring"
+	"github.com/kevinburke/nacl"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
+)
+
+func TestFileSource_Load(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "keypair.yaml")
+	pair := generateKeyPair(t, time.Time{})
+	writeKeyPair(t, path, pair)
+
+	k := keyring.New()
+	assert.NilError(t, keyring.NewFileSource(k, path).Load(context.Background()))
+	assert.Assert(t, nacl.Verify32(k.PublicKey(), pair.Public))
+
+	assert.NilError(t, os.WriteFile(path, []byte("public: AAAA"), 0o600))
+	assert.Assert(t, keyring.NewFileSource(k, path).Load(context.Background()) != nil)
+	assert.Assert(t, nacl.Verify32(k.PublicKey(), pair.Public))
+}
+
+func TestFileSource_Start(t *testing.T) {
+	// Mimic the layout of a Kubernetes Secret volume, where keypair.yaml links through
+	// the ..data symlink into a timestamped directory.
+	dir := t.TempDir()
+	path := filepath.Join(dir, "keypair.yaml")
+
+	first := generateKeyPair(t, time.Time{})
+	assert.NilError(t, os.Mkdir(filepath.Join(dir, "..first"), 0o700))
+	writeKeyPair(t, filepath.Join(dir, "..first", "keypair.yaml"), first)
+	assert.NilError(t, os.Symlink("..first", filepath.Join(dir, "..data")))
+	assert.NilError(t, os.Symlink(filepath.Join("..data", "keypair.yaml"), path))
+
+	k := keyring.New()
+	source := keyring.NewFileSource(k, path)
+	assert.NilError(t, source.Load(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- source.Start(ctx) }()
+	defer func() {
+		cancel()
+		assert.NilError(t, <-done)
+	}()
+
+	second := generateKeyPair(t, time.Time{})
+	assert.NilError(t, os.Mkdir(filepath.Join(dir, "..second"), 0o700))
+	writeKeyPair(t, filepath.Join(dir, "..second", "keypair.yaml"), second)
+
+	// Give the watcher time to start before swapping the symlink.
+	poll.WaitOn(t, func(poll.LogT) poll.Result {
+		assert.NilError(t, os.Symlink("..second", filepath.Join(dir, "..data_tmp")))
+		assert.NilError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+
+		if nacl.Verify32(k.PublicKey(), second.Public) {
+			return poll.Success()
+		}
+		return poll.Continue("keypair not reloaded")
+	}, poll.WithTimeout(5*time.Second), poll.WithDelay(50*time.Millisecond))
+}
+
+func writeKeyPair(t *testing.T, path string, pair testKeyPair) {
+	t.Helper()
+
+	data, err := keyring.KeyPairToYAML(pair.Public, pair.private, pair.mlkemSeed)
+	assert.NilError(t, err)
+	assert.NilError(t, os.WriteFile(path, data, 0o600))
+}
+
+// staticUnwrapper unwraps a single wrapped private key.
+type staticUnwrapper struct {
+	wrapped string
+	pri     nacl.Key
+}
+
+func (u staticUnwrapper) Unwrap(_ context.Context, wrapped string) (nacl.Key, error) {
+	if wrapped != u.wrapped {
+		return nil, errors.New("invalid ciphertext")
+	}
+	return u.pri, nil
+}
+
+func TestFileSource_LoadWrapped(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keypair.yaml")
+	pair := generateKeyPair(t, time.Time{})
+	other := generateKeyPair(t, time.Time{})
+
+	data := fmt.Sprintf("public: %s\nwrappedPrivate: vault:v1:wrapped\n", base64.StdEncoding.EncodeToString(pair.Public[:]))
+	assert.NilError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	k := keyring.New()
+	source := keyring.NewFileSource(k, path, keyring.WithUnwrapper(staticUnwrapper{wrapped: "vault:v1:wrapped", pri: pair.private}))
+	assert.NilError(t, source.Load(ctx))
+	assert.Assert(t, nacl.Verify32(k.PublicKey(), pair.Public))
+
+	mismatched := keyring.NewFileSource(keyring.New(), path, keyring.WithUnwrapper(staticUnwrapper{wrapped: "vault:v1:wrapped", pri: other.private}))
+	assert.ErrorContains(t, mismatched.Load(ctx), "does not match public key")
+
+	invalid := keyring.NewFileSource(keyring.New(), path, keyring.WithUnwrapper(staticUnwrapper{wrapped: "vault:v1:other"}))
+	assert.ErrorContains(t, invalid.Load(ctx), "invalid ciphertext")
+}
@@ -0,0 +1,28 @@
+package keyring
+
+import (
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+)
+
+// HybridPublicKey returns the Curve25519 and ML-KEM-768 hybrid public key of the active
+// keypair, or of the keypair derived from it for the namespace if set. It returns nil if
+// the Keyring is empty, and lockboxv1.ErrNoDecapsulationKey if the active keypair has no
+// ML-KEM-768 seed. It returns ErrNoNamespaceKeys if a namespace is set but the active
+// keypair's private key isn't held in memory.
+func (k *Keyring) HybridPublicKey(namespace string) ([]byte, error) {
+	pairs := k.KeyPairs()
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+
+	pub, provider := pairs[0].Public, pairs[0].Private
+	if namespace != "" {
+		var err error
+		pub, provider, err = k.namespaceProvider(pairs[0], namespace)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return lockboxv1.HybridPublicKey(pub, provider)
+}
@@ -0,0 +1,125 @@
+// Package keyring manages the keypairs used by the Lockbox controller to unlock Lockboxes.
+package keyring
+
+import (
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kevinburke/nacl"
+)
+
+// KeyPair is a Curve25519 public key and the provider of its private key.
+type KeyPair struct {
+	Public  nacl.Key
+	Private KeyProvider
+
+	// Created is when the keypair was created, if known.
+	Created time.Time
+}
+
+// Keyring holds the keypairs able to unlock Lockboxes. The most recently created keypair is
+// active, and its public key is offered to clients sealing new Lockboxes. A Keyring is safe
+// for concurrent use, and its keypairs may be replaced at any time.
+type Keyring struct {
+	mu          sync.RWMutex
+	pairs       []KeyPair
+	subscribers []chan struct{}
+
+	// seeds caches namespace key derivation seeds by the hex encoded public key. Seeds are
+	// as sensitive as the private keys, so are only kept for keypairs the Keyring holds.
+	seeds map[string]nacl.Key
+}
+
+// New creates a Keyring holding the provided keypairs.
+func New(pairs ...KeyPair) *Keyring {
+	k := &Keyring{}
+	k.Replace(pairs...)
+
+	return k
+}
+
+// Replace atomically replaces all keypairs held by the Keyring.
+func (k *Keyring) Replace(pairs ...KeyPair) {
+	sorted := make([]KeyPair, len(pairs))
+	copy(sorted, pairs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Created.After(sorted[j].Created)
+	})
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.pairs = sorted
+	for id := range k.seeds {
+		if !k.holds(id) {
+			delete(k.seeds, id)
+		}
+	}
+	for _, ch := range k.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// holds reports whether the Keyring holds the keypair with the hex encoded public key. The
+// caller must hold k.mu.
+func (k *Keyring) holds(id string) bool {
+	for _, pair := range k.pairs {
+		if hex.EncodeToString(pair.Public[:]) == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscribe returns a channel that receives a value after the Keyring's keypairs are
+// replaced. Notifications are coalesced while the subscriber is busy, so a receive means
+// at least one replacement happened since the previous receive.
+func (k *Keyring) Subscribe() <-chan struct{} {
+	ch := make(chan struct{}, 1)
+
+	k.mu.Lock()
+	k.subscribers = append(k.subscribers, ch)
+	k.mu.Unlock()
+
+	return ch
+}
+
+// KeyPairs returns the keypairs held by the Keyring, starting with the active keypair.
+func (k *Keyring) KeyPairs() []KeyPair {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	pairs := make([]KeyPair, len(k.pairs))
+	copy(pairs, k.pairs)
+	return pairs
+}
+
+// PublicKey returns the public key of the active keypair, or nil if the Keyring is empty.
+func (k *Keyring) PublicKey() nacl.Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if len(k.pairs) == 0 {
+		return nil
+	}
+	return k.pairs[0].Public
+}
+
+// PrivateKey returns the provider of the private key matching the provided public key, if any.
+func (k *Keyring) PrivateKey(pub nacl.Key) (KeyProvider, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	for _, pair := range k.pairs {
+		if nacl.Verify32(pair.Public, pub) {
+			return pair.Private, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,39 @@
+package keyring
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/kevinburke/nacl/box"
+	"gotest.tools/v3/assert"
+)
+
+func TestKeyring_ReplacePrunesSeeds(t *testing.T) {
+	retiredPub, retiredPri, err := box.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+	activePub, activePri, err := box.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+
+	retired := KeyPair{Public: retiredPub, Private: StaticKey(retiredPri), Created: time.Unix(1e9, 0)}
+	active := KeyPair{Public: activePub, Private: StaticKey(activePri), Created: time.Unix(2e9, 0)}
+	k := New(retired, active)
+
+	_, err = k.NamespacePublicKey("example")
+	assert.NilError(t, err)
+	_, err = k.derivationSeed(retired)
+	assert.NilError(t, err)
+	assert.Equal(t, len(k.seeds), 2)
+
+	k.Replace(active)
+	_, ok := k.seeds[hex.EncodeToString(retiredPub[:])]
+	assert.Assert(t, !ok)
+	_, ok = k.seeds[hex.EncodeToString(activePub[:])]
+	assert.Assert(t, ok)
+
+	// Seeds of keypairs no longer held aren't cached again.
+	_, err = k.derivationSeed(retired)
+	assert.NilError(t, err)
+	assert.Equal(t, len(k.seeds), 1)
+}
@@ -0,0 +1,79 @@
+package keyring_test
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/lockbox/pkg/keyring"
+	"github.com/kevinburke/nacl"
+	"github.com/kevinburke/nacl/box"
+	"gotest.tools/v3/assert"
+)
+
+func TestKeyring(t *testing.T) {
+	older := generateKeyPair(t, time.Unix(1e9, 0))
+	newer := generateKeyPair(t, time.Unix(2e9, 0))
+
+	k := keyring.New()
+	assert.Assert(t, k.PublicKey() == nil)
+
+	k.Replace(older.KeyPair, newer.KeyPair)
+	assert.Assert(t, nacl.Verify32(k.PublicKey(), newer.Public))
+
+	pri, ok := k.PrivateKey(older.Public)
+	assert.Assert(t, ok)
+	assert.Equal(t, pri, older.Private)
+
+	pairs := k.KeyPairs()
+	assert.Equal(t, len(pairs), 2)
+	assert.Assert(t, nacl.Verify32(pairs[0].Public, newer.Public))
+	assert.Assert(t, nacl.Verify32(pairs[1].Public, older.Public))
+
+	k.Replace(newer.KeyPair)
+	_, ok = k.PrivateKey(older.Public)
+	assert.Assert(t, !ok)
+}
+
+// testKeyPair is a keyring.KeyPair that also holds the raw private key and ML-KEM-768
+// seed, for serializing.
+type testKeyPair struct {
+	keyring.KeyPair
+	private   nacl.Key
+	mlkemSeed []byte
+}
+
+func generateKeyPair(t *testing.T, created time.Time) testKeyPair {
+	t.Helper()
+
+	pub, pri, err := box.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+	mlkemSeed, err := keyring.GenerateMLKEMSeed()
+	assert.NilError(t, err)
+
+	return testKeyPair{
+		KeyPair:   keyring.KeyPair{Public: pub, Private: keyring.StaticHybridKey(pri, mlkemSeed), Created: created},
+		private:   pri,
+		mlkemSeed: mlkemSeed,
+	}
+}
+
+func TestKeyring_Subscribe(t *testing.T) {
+	k := keyring.New()
+	updates := k.Subscribe()
+
+	k.Replace(generateKeyPair(t, time.Unix(1e9, 0)).KeyPair)
+	k.Replace(generateKeyPair(t, time.Unix(2e9, 0)).KeyPair)
+
+	select {
+	case <-updates:
+	default:
+		t.Fatal("expected update notification")
+	}
+
+	select {
+	case <-updates:
+		t.Fatal("expected notifications to be coalesced")
+	default:
+	}
+}
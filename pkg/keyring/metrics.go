@@ -0,0 +1,118 @@
+package keyring
+
+import (
+	"context"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// countInterval is how long the Collector reuses its counts of sealed Lockboxes between
+// scrapes, as counting lists every Lockbox and derives namespace keys.
+const countInterval = 5 * time.Minute
+
+// Collector exports metrics about each keypair held by a Keyring. Keypairs are labelled
+// by their hex encoded public key, matching the peer label of kube_lockbox_peer.
+type Collector struct {
+	keyring *Keyring
+	client  client.Reader
+
+	age       *prometheus.Desc
+	active    *prometheus.Desc
+	lockboxes *prometheus.Desc
+
+	mu      sync.Mutex
+	counts  map[string]int
+	counted time.Time
+
+	now func() time.Time
+}
+
+// NewCollector creates a Collector for the Keyring, counting the Lockboxes sealed to each
+// keypair with the provided client.
+func NewCollector(keyring *Keyring, c client.Reader) *Collector {
+	return &Collector{
+		keyring: keyring,
+		client:  c,
+		age: prometheus.NewDesc(
+			"kube_lockbox_keypair_age_seconds",
+			"Seconds since the keypair was created, if known",
+			[]string{"peer"}, nil,
+		),
+		active: prometheus.NewDesc(
+			"kube_lockbox_keypair_active",
+			"Whether the keypair's public key is offered for locking new Lockboxes",
+			[]string{"peer"}, nil,
+		),
+		lockboxes: prometheus.NewDesc(
+			"kube_lockbox_keypair_lockboxes",
+			"Number of Lockboxes sealed to the keypair, directly or through a namespace key",
+			[]string{"peer"}, nil,
+		),
+		now: time.Now,
+	}
+}
+
+// Describe implements Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.age
+	ch <- c.active
+	ch <- c.lockboxes
+}
+
+// Collect implements Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	now := c.now()
+
+	pairs := c.keyring.KeyPairs()
+	counts, err := c.sealedLockboxes(now, pairs)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.lockboxes, err)
+	}
+
+	for i, pair := range pairs {
+		peer := hex.EncodeToString(pair.Public[:])
+
+		active := 0.0
+		if i == 0 {
+			active = 1
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.active, prometheus.GaugeValue, active, peer)
+		if count, ok := counts[peer]; ok {
+			ch <- prometheus.MustNewConstMetric(c.lockboxes, prometheus.GaugeValue, float64(count), peer)
+		}
+		if !pair.Created.IsZero() {
+			ch <- prometheus.MustNewConstMetric(c.age, prometheus.GaugeValue, now.Sub(pair.Created).Seconds(), peer)
+		}
+	}
+}
+
+// sealedLockboxes returns the number of Lockboxes sealed to each keypair, counting them
+// again only once the previous counts are older than countInterval or the keypairs change.
+func (c *Collector) sealedLockboxes(now time.Time, pairs []KeyPair) (map[string]int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current := c.counts != nil && now.Sub(c.counted) < countInterval && len(c.counts) == len(pairs)
+	for _, pair := range pairs {
+		if !current {
+			break
+		}
+		_, current = c.counts[hex.EncodeToString(pair.Public[:])]
+	}
+	if current {
+		return c.counts, nil
+	}
+
+	counts, err := c.keyring.SealedLockboxes(context.Background(), c.client)
+	if err != nil {
+		return nil, err
+	}
+
+	c.counts, c.counted = counts, now
+	return counts, nil
+}
@@ -0,0 +1,83 @@
+package keyring_test
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"github.com/cloudflare/lockbox/pkg/keyring"
+	"github.com/kevinburke/nacl"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestCollector(t *testing.T) {
+	older := generateKeyPair(t, time.Time{})
+	newer := generateKeyPair(t, time.Now())
+	k := keyring.New(older.KeyPair, newer.KeyPair)
+
+	namespacePub, _, err := keyring.DeriveNamespaceKey(older.Private, "example")
+	assert.NilError(t, err)
+	c := newFakeClient(t,
+		sealedLockbox("direct", "example", older.Public),
+		sealedLockbox("derived", "example", namespacePub),
+		sealedLockbox("other", "other", namespacePub),
+	)
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(keyring.NewCollector(k, c))
+
+	expected := fmt.Sprintf(`
+# HELP kube_lockbox_keypair_active Whether the keypair's public key is offered for locking new Lockboxes
+# TYPE kube_lockbox_keypair_active gauge
+kube_lockbox_keypair_active{peer=%[1]q} 0
+kube_lockbox_keypair_active{peer=%[2]q} 1
+# HELP kube_lockbox_keypair_lockboxes Number of Lockboxes sealed to the keypair, directly or through a namespace key
+# TYPE kube_lockbox_keypair_lockboxes gauge
+kube_lockbox_keypair_lockboxes{peer=%[1]q} 2
+kube_lockbox_keypair_lockboxes{peer=%[2]q} 0
+`, hex.EncodeToString(older.Public[:]), hex.EncodeToString(newer.Public[:]))
+
+	err = testutil.GatherAndCompare(reg, strings.NewReader(expected), "kube_lockbox_keypair_active", "kube_lockbox_keypair_lockboxes")
+	assert.NilError(t, err)
+	assert.Equal(t, testutil.CollectAndCount(keyring.NewCollector(k, c), "kube_lockbox_keypair_age_seconds"), 1)
+}
+
+func TestCollector_CachesCounts(t *testing.T) {
+	older := generateKeyPair(t, time.Time{})
+	k := keyring.New(older.KeyPair)
+
+	lists := 0
+	c := interceptor.NewClient(newFakeClient(t, sealedLockbox("direct", "example", older.Public)).(client.WithWatch), interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			lists++
+			return c.List(ctx, list, opts...)
+		},
+	})
+	collector := keyring.NewCollector(k, c)
+
+	testutil.CollectAndCount(collector)
+	testutil.CollectAndCount(collector)
+	assert.Equal(t, lists, 1)
+
+	// Changing the keypairs counts again, so new keypairs are reported.
+	k.Replace(older.KeyPair, generateKeyPair(t, time.Now()).KeyPair)
+	assert.Equal(t, testutil.CollectAndCount(collector, "kube_lockbox_keypair_lockboxes"), 2)
+	assert.Equal(t, lists, 2)
+}
+
+// sealedLockbox returns a Lockbox in the namespace sealed to the peer public key.
+func sealedLockbox(name, namespace string, peer nacl.Key) *lockboxv1.Lockbox {
+	return &lockboxv1.Lockbox{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       lockboxv1.LockboxSpec{Peer: peer[:]},
+	}
+}
@@ -0,0 +1,48 @@
+//go:build !cgo
+
+package pkcs11
+
+import (
+	"errors"
+
+	"github.com/kevinburke/nacl"
+)
+
+// Config identifies an X25519 keypair held in a PKCS#11 token.
+type Config struct {
+	Module     string
+	TokenLabel string
+	KeyLabel   string
+	PIN        string
+}
+
+// Key performs X25519 key agreement with a private key held in a PKCS#11 token.
+type Key struct{}
+
+// errNoCgo is returned when PKCS#11 support isn't compiled in.
+var errNoCgo = errors.New("PKCS#11 support requires building with cgo")
+
+// Open always fails, as PKCS#11 modules can't be loaded without cgo.
+func Open(cfg Config) (*Key, error) {
+	return nil, errNoCgo
+}
+
+// PublicKey returns nil.
+func (k *Key) PublicKey() nacl.Key {
+	return nil
+}
+
+// SharedKey always fails.
+func (k *Key) SharedKey(peer nacl.Key) (nacl.Key, error) {
+	return nil, errNoCgo
+}
+
+// X25519 always fails.
+func (k *Key) X25519(peer nacl.Key) ([]byte, error) {
+	return nil, errNoCgo
+}
+
+// Close does nothing.
+func (k *Key) Close() error {
+	return nil
+}
@@ -0,0 +1,234 @@
+//go:build cgo
+
+// Package pkcs11 provides a keyring.KeyProvider for X25519 private keys held in a PKCS#11
+// token, such as a hardware security module. Key agreement is performed inside the token,
+// so the private key is never exposed to the controller.
+package pkcs11
+
+import (
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/kevinburke/nacl"
+	"github.com/miekg/pkcs11"
+	"golang.org/x/crypto/salsa20/salsa"
+)
+
+// Config identifies an X25519 keypair held in a PKCS#11 token.
+type Config struct {
+	// Module is the path to the PKCS#11 module shared library.
+	Module string
+
+	// TokenLabel is the label of the token holding the keypair.
+	TokenLabel string
+
+	// KeyLabel is the label of the keypair's public and private key objects.
+	KeyLabel string
+
+	// PIN is the user PIN used to log into the token.
+	PIN string
+}
+
+// Key performs X25519 key agreement with a private key held in a PKCS#11 token.
+// It implements keyring.KeyProvider.
+type Key struct {
+	mu      sync.Mutex
+	ctx     *pkcs11.Ctx
+	session pkcs11.SessionHandle
+	private pkcs11.ObjectHandle
+	public  nacl.Key
+}
+
+// zeros is the HSalsa20 input used by box.Precompute.
+var zeros [16]byte
+
+// Open logs into the configured token and finds the keypair. The Key must be closed after use.
+func Open(cfg Config) (*Key, error) {
+	ctx := pkcs11.New(cfg.Module)
+	if ctx == nil {
+		return nil, fmt.Errorf("unable to load PKCS#11 module %q", cfg.Module)
+	}
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, err
+	}
+
+	k := &Key{ctx: ctx}
+	if err := k.open(cfg); err != nil {
+		k.Close()
+		return nil, err
+	}
+
+	return k, nil
+}
+
+func (k *Key) open(cfg Config) error {
+	slot, err := k.findSlot(cfg.TokenLabel)
+	if err != nil {
+		return err
+	}
+
+	k.session, err = k.ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return err
+	}
+	if err := k.ctx.Login(k.session, pkcs11.CKU_USER, cfg.PIN); err != nil {
+		return err
+	}
+
+	k.private, err = k.findObject(pkcs11.CKO_PRIVATE_KEY, cfg.KeyLabel)
+	if err != nil {
+		return err
+	}
+
+	public, err := k.findObject(pkcs11.CKO_PUBLIC_KEY, cfg.KeyLabel)
+	if err != nil {
+		return err
+	}
+	attrs, err := k.ctx.GetAttributeValue(k.session, public, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+	})
+	if err != nil {
+		return err
+	}
+
+	k.public, err = decodePoint(attrs[0].Value)
+	return err
+}
+
+// findSlot returns the slot holding the token with the label.
+func (k *Key) findSlot(label string) (uint, error) {
+	slots, err := k.ctx.GetSlotList(true)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, slot := range slots {
+		info, err := k.ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, err
+		}
+		if info.Label == label {
+			return slot, nil
+		}
+	}
+
+	return 0, fmt.Errorf("PKCS#11 token %q not found", label)
+}
+
+// findObject returns the single object of the class with the label.
+func (k *Key) findObject(class uint, label string) (pkcs11.ObjectHandle, error) {
+	err := k.ctx.FindObjectsInit(k.session, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	objs, _, err := k.ctx.FindObjects(k.session, 2)
+	if finalErr := k.ctx.FindObjectsFinal(k.session); err == nil {
+		err = finalErr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	switch len(objs) {
+	case 0:
+		return 0, fmt.Errorf("PKCS#11 key %q not found", label)
+	case 1:
+		return objs[0], nil
+	default:
+		return 0, fmt.Errorf("multiple PKCS#11 keys labelled %q", label)
+	}
+}
+
+// decodePoint decodes a CKA_EC_POINT value for an X25519 public key. Tokens return either
+// the raw 32 byte key, or the key wrapped in a DER OCTET STRING.
+func decodePoint(point []byte) (nacl.Key, error) {
+	if len(point) != nacl.KeySize {
+		var raw []byte
+		if _, err := asn1.Unmarshal(point, &raw); err != nil {
+			return nil, fmt.Errorf("unable to decode PKCS#11 public key: %w", err)
+		}
+		point = raw
+	}
+
+	if len(point) != nacl.KeySize {
+		return nil, fmt.Errorf("incorrect PKCS#11 public key length: %d, should be %d", len(point), nacl.KeySize)
+	}
+
+	pub := new([nacl.KeySize]byte)
+	copy(pub[:], point)
+	return pub, nil
+}
+
+// PublicKey returns the public key of the keypair.
+func (k *Key) PublicKey() nacl.Key {
+	return k.public
+}
+
+// SharedKey implements keyring.KeyProvider by deriving the X25519 shared secret inside the
+// token, then applying HSalsa20 as box.Precompute does.
+func (k *Key) SharedKey(peer nacl.Key) (nacl.Key, error) {
+	secret, err := k.X25519(peer)
+	if err != nil {
+		return nil, err
+	}
+
+	sharedKey := new([nacl.KeySize]byte)
+	copy(sharedKey[:], secret)
+	salsa.HSalsa20(sharedKey, &zeros, sharedKey, &salsa.Sigma)
+	return sharedKey, nil
+}
+
+// X25519 derives the raw X25519 shared secret inside the token.
+func (k *Key) X25519(peer nacl.Key) ([]byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	mech := []*pkcs11.Mechanism{
+		pkcs11.NewMechanism(pkcs11.CKM_ECDH1_DERIVE, pkcs11.NewECDH1DeriveParams(pkcs11.CKD_NULL, nil, peer[:])),
+	}
+	derived, err := k.ctx.DeriveKey(k.session, mech, k.private, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_GENERIC_SECRET),
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE_LEN, nacl.KeySize),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, false),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, false),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer k.ctx.DestroyObject(k.session, derived) //nolint:errcheck
+
+	attrs, err := k.ctx.GetAttributeValue(k.session, derived, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE, nil),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(attrs[0].Value) != nacl.KeySize {
+		return nil, errors.New("PKCS#11 token derived shared secret of incorrect length")
+	}
+
+	return attrs[0].Value, nil
+}
+
+// Close logs out of the token and unloads the PKCS#11 module.
+func (k *Key) Close() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.session != 0 {
+		_ = k.ctx.Logout(k.session)
+		_ = k.ctx.CloseSession(k.session)
+	}
+	err := k.ctx.Finalize()
+	k.ctx.Destroy()
+	return err
+}
@@ -0,0 +1,120 @@
+//go:build softhsm && cgo
+
+package pkcs11_test
+
+import (
+	"crypto/rand"
+	"encoding/asn1"
+	"os"
+	"path/filepath"
+	"testing"
+
+	lockboxpkcs11 "github.com/cloudflare/lockbox/pkg/keyring/pkcs11"
+	"github.com/kevinburke/nacl"
+	"github.com/kevinburke/nacl/box"
+	"github.com/miekg/pkcs11"
+	"gotest.tools/v3/assert"
+)
+
+const (
+	ckkECMontgomery           = 0x00000041
+	ckmECMontgomeryKeyPairGen = 0x00001056
+	tokenLabel, keyLabel      = "lockbox", "lockbox-keypair"
+	soPIN, userPIN            = "1234", "5678"
+)
+
+// oidX25519 identifies Curve25519 in CKA_EC_PARAMS, per RFC 8410.
+var oidX25519 = asn1.ObjectIdentifier{1, 3, 101, 110}
+
+// TestKey_SharedKey requires SOFTHSM2_MODULE to be set to the path of SoftHSM's PKCS#11
+// module, built with support for X25519 (CKK_EC_MONTGOMERY) keys. For example:
+//
+//	SOFTHSM2_MODULE=/usr/lib/softhsm/libsofthsm2.so go test -tags softhsm ./pkg/keyring/pkcs11
+func TestKey_SharedKey(t *testing.T) {
+	module := os.Getenv("SOFTHSM2_MODULE")
+	if module == "" {
+		t.Skip("SOFTHSM2_MODULE not set")
+	}
+	initToken(t, module)
+
+	key, err := lockboxpkcs11.Open(lockboxpkcs11.Config{
+		Module:     module,
+		TokenLabel: tokenLabel,
+		KeyLabel:   keyLabel,
+		PIN:        userPIN,
+	})
+	assert.NilError(t, err)
+	defer key.Close()
+
+	senderPub, senderPri, err := box.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+
+	sharedKey, err := key.SharedKey(senderPub)
+	assert.NilError(t, err)
+	assert.Assert(t, nacl.Verify32(sharedKey, box.Precompute(key.PublicKey(), senderPri)))
+}
+
+// initToken creates a SoftHSM token holding an X25519 keypair, stored in a temporary directory.
+func initToken(t *testing.T, module string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	assert.NilError(t, os.Mkdir(filepath.Join(dir, "tokens"), 0o700))
+	conf := filepath.Join(dir, "softhsm2.conf")
+	assert.NilError(t, os.WriteFile(conf, []byte("directories.tokendir = "+filepath.Join(dir, "tokens")+"\nobjectstore.backend = file\n"), 0o600))
+	t.Setenv("SOFTHSM2_CONF", conf)
+
+	ctx := pkcs11.New(module)
+	assert.Assert(t, ctx != nil)
+	assert.NilError(t, ctx.Initialize())
+	defer ctx.Destroy()
+	defer ctx.Finalize() //nolint:errcheck
+
+	slots, err := ctx.GetSlotList(false)
+	assert.NilError(t, err)
+	assert.Assert(t, len(slots) > 0)
+	assert.NilError(t, ctx.InitToken(slots[0], soPIN, tokenLabel))
+
+	// SoftHSM reassigns the slot of an initialized token.
+	slots, err = ctx.GetSlotList(true)
+	assert.NilError(t, err)
+	var slot uint
+	for _, s := range slots {
+		info, err := ctx.GetTokenInfo(s)
+		assert.NilError(t, err)
+		if info.Label == tokenLabel {
+			slot = s
+		}
+	}
+
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	assert.NilError(t, err)
+	defer ctx.CloseSession(session) //nolint:errcheck
+
+	assert.NilError(t, ctx.Login(session, pkcs11.CKU_SO, soPIN))
+	assert.NilError(t, ctx.InitPIN(session, userPIN))
+	assert.NilError(t, ctx.Logout(session))
+	assert.NilError(t, ctx.Login(session, pkcs11.CKU_USER, userPIN))
+
+	params, err := asn1.Marshal(oidX25519)
+	assert.NilError(t, err)
+
+	_, _, err = ctx.GenerateKeyPair(session,
+		[]*pkcs11.Mechanism{pkcs11.NewMechanism(ckmECMontgomeryKeyPairGen, nil)},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, ckkECMontgomery),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, params),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, keyLabel),
+		},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, ckkECMontgomery),
+			pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_DERIVE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, keyLabel),
+		},
+	)
+	assert.NilError(t, err)
+}
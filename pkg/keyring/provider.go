@@ -0,0 +1,61 @@
+package keyring
+
+import (
+	"crypto/mlkem"
+
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"github.com/kevinburke/nacl"
+	"github.com/kevinburke/nacl/box"
+	"golang.org/x/crypto/curve25519"
+)
+
+// KeyProvider performs key agreement using a private key, which may be held outside of the
+// controller's memory, such as in a hardware security module.
+type KeyProvider interface {
+	// SharedKey returns the NaCL box shared key between the private key and the peer's
+	// public key, as computed by box.Precompute.
+	SharedKey(peer nacl.Key) (nacl.Key, error)
+}
+
+// StaticKey returns a KeyProvider for a private key held in memory.
+func StaticKey(pri nacl.Key) KeyProvider {
+	return staticKey{pri: pri}
+}
+
+// StaticHybridKey returns a KeyProvider for a private key held in memory, along with the
+// seed of its ML-KEM-768 decapsulation key for AlgorithmX25519MLKEM768 Lockboxes. Without
+// a seed, it's equivalent to StaticKey.
+func StaticHybridKey(pri nacl.Key, mlkemSeed []byte) KeyProvider {
+	s := staticKey{pri: pri}
+	if mlkemSeed != nil {
+		s.mlkemSeed = &mlkemSeed
+	}
+	return s
+}
+
+// staticKey implements KeyProvider. It's comparable, so that providers can be compared
+// for equality.
+type staticKey struct {
+	pri       nacl.Key
+	mlkemSeed *[]byte
+}
+
+// SharedKey implements KeyProvider
+func (s staticKey) SharedKey(peer nacl.Key) (nacl.Key, error) {
+	return box.Precompute(peer, s.pri), nil
+}
+
+// X25519 returns the raw X25519 shared secret, for Lockbox algorithms that don't use
+// NaCl box.
+func (s staticKey) X25519(peer nacl.Key) ([]byte, error) {
+	return curve25519.X25519(s.pri[:], peer[:])
+}
+
+// DecapsulationKey implements lockboxv1.Decapsulator
+func (s staticKey) DecapsulationKey() (*mlkem.DecapsulationKey768, error) {
+	if s.mlkemSeed == nil {
+		return nil, lockboxv1.ErrNoDecapsulationKey
+	}
+
+	return mlkem.NewDecapsulationKey768(*s.mlkemSeed)
+}
@@ -0,0 +1,170 @@
+package keyring
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"github.com/kevinburke/nacl"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Publisher publishes the Keyring's public keys as cluster-scoped LockboxKeys, so clients
+// can discover the active public key, and each namespace's key, with only read access to
+// LockboxKeys.
+type Publisher struct {
+	keys    *Keyring
+	client  client.Client
+	updates <-chan struct{}
+}
+
+// NewPublisher creates a Publisher for the Keyring, managing LockboxKeys with the provided
+// client.
+func NewPublisher(keys *Keyring, c client.Client) *Publisher {
+	return &Publisher{
+		keys:    keys,
+		client:  c,
+		updates: keys.Subscribe(),
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Only the leader publishes,
+// so replicas don't race to update LockboxKeys.
+func (p *Publisher) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable by publishing the public keys on start and after each
+// keypair change, until the context is cancelled.
+func (p *Publisher) Start(ctx context.Context) error {
+	log := logf.FromContext(ctx).WithName("keyring-publisher")
+
+	for {
+		var retry <-chan time.Time
+		if err := p.Publish(ctx); err != nil {
+			log.Error(err, "unable to publish public keys")
+			retry = time.After(retryInterval)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-p.updates:
+		case <-retry:
+		}
+	}
+}
+
+// Publish creates or updates a LockboxKey for each keypair held by the Keyring, and for each
+// namespace's key derived from the active keypair, and deletes LockboxKeys for keys no
+// longer held.
+func (p *Publisher) Publish(ctx context.Context) error {
+	want := map[string]*lockboxv1.LockboxKey{}
+	pairs := p.keys.KeyPairs()
+	for i, pair := range pairs {
+		key, err := publishedKey(pair.Public, pair.Private, pair.Created)
+		if err != nil {
+			return err
+		}
+		key.Spec.NamespaceKeys = p.keys.derivesNamespaceKeys(pair)
+		key.Spec.Active = i == 0
+		want[key.Name] = key
+	}
+
+	// Only the active keypair's namespace keys are published, as only they lock new
+	// Lockboxes.
+	if len(pairs) > 0 && p.keys.derivesNamespaceKeys(pairs[0]) {
+		namespaces := &corev1.NamespaceList{}
+		if err := p.client.List(ctx, namespaces); err != nil {
+			return err
+		}
+
+		for _, ns := range namespaces.Items {
+			pub, provider, err := p.keys.namespaceProvider(pairs[0], ns.Name)
+			if err != nil {
+				return err
+			}
+
+			key, err := publishedKey(pub, provider, pairs[0].Created)
+			if err != nil {
+				return err
+			}
+			key.Labels = map[string]string{lockboxv1.LockboxKeyNamespaceLabel: ns.Name}
+			key.Spec.Namespace = ns.Name
+			key.Spec.Active = true
+			want[key.Name] = key
+		}
+	}
+
+	existing := &lockboxv1.LockboxKeyList{}
+	if err := p.client.List(ctx, existing); err != nil {
+		return err
+	}
+
+	for i := range existing.Items {
+		key := &existing.Items[i]
+
+		desired, ok := want[key.Name]
+		if !ok {
+			err := p.client.Delete(ctx, key, client.Preconditions{UID: &key.UID})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+			continue
+		}
+		delete(want, key.Name)
+
+		if equality.Semantic.DeepEqual(key.Spec, desired.Spec) && equality.Semantic.DeepEqual(key.Labels, desired.Labels) {
+			continue
+		}
+		key.Labels = desired.Labels
+		key.Spec = desired.Spec
+		if err := p.client.Update(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range want {
+		if err := p.client.Create(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Reconcile implements reconcile.Reconciler by publishing the public keys whenever a
+// namespace is created or deleted. The request is ignored.
+func (p *Publisher) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	return reconcile.Result{}, p.Publish(ctx)
+}
+
+// publishedKey returns a LockboxKey publishing the public key, named by its fingerprint.
+func publishedKey(pub nacl.Key, provider KeyProvider, created time.Time) (*lockboxv1.LockboxKey, error) {
+	// Keypairs without an ML-KEM-768 seed are published without a hybrid key.
+	hybrid, err := lockboxv1.HybridPublicKey(pub, provider)
+	if err != nil && !errors.Is(err, lockboxv1.ErrNoDecapsulationKey) {
+		return nil, err
+	}
+
+	key := &lockboxv1.LockboxKey{
+		ObjectMeta: metav1.ObjectMeta{Name: Fingerprint(pub)},
+		Spec: lockboxv1.LockboxKeySpec{
+			PublicKey:       pub[:],
+			HybridPublicKey: hybrid,
+			Fingerprint:     Fingerprint(pub),
+		},
+	}
+	if !created.IsZero() {
+		t := metav1.NewTime(created).Rfc3339Copy()
+		key.Spec.Created = &t
+	}
+	return key, nil
+}
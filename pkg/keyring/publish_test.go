@@ -0,0 +1,126 @@
+package keyring_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"github.com/cloudflare/lockbox/pkg/keyring"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestPublisher_Publish(t *testing.T) {
+	ctx := context.Background()
+	older := generateKeyPair(t, time.Unix(1e9, 0))
+	newer := generateKeyPair(t, time.Unix(2e9, 0))
+
+	stale := &lockboxv1.LockboxKey{
+		ObjectMeta: metav1.ObjectMeta{Name: "stale"},
+		Spec:       lockboxv1.LockboxKeySpec{Fingerprint: "stale", Active: true},
+	}
+	c := newFakeClient(t, stale)
+	k := keyring.New(older.KeyPair, newer.KeyPair)
+	publisher := keyring.NewPublisher(k, c)
+
+	assert.NilError(t, publisher.Publish(ctx))
+
+	keys := &lockboxv1.LockboxKeyList{}
+	assert.NilError(t, c.List(ctx, keys))
+	published := map[string]lockboxv1.LockboxKeySpec{}
+	for _, key := range keys.Items {
+		published[key.Name] = key.Spec
+	}
+	assert.Equal(t, len(published), 2)
+
+	active := published[keyring.Fingerprint(newer.Public)]
+	assert.Assert(t, active.Active)
+	assert.DeepEqual(t, active.PublicKey, newer.Public[:])
+	assert.Assert(t, active.Created.Equal(&metav1.Time{Time: newer.Created}))
+	assert.Assert(t, active.NamespaceKeys)
+	hybrid, err := lockboxv1.HybridPublicKey(newer.Public, newer.Private)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, active.HybridPublicKey, hybrid)
+
+	inactive := published[keyring.Fingerprint(older.Public)]
+	assert.Assert(t, !inactive.Active)
+	assert.DeepEqual(t, inactive.PublicKey, older.Public[:])
+
+	// Once the newer keypair is removed, the older keypair becomes active.
+	k.Replace(older.KeyPair)
+	assert.NilError(t, publisher.Publish(ctx))
+
+	assert.NilError(t, c.List(ctx, keys))
+	assert.Equal(t, len(keys.Items), 1)
+	assert.Equal(t, keys.Items[0].Name, keyring.Fingerprint(older.Public))
+	assert.Assert(t, keys.Items[0].Spec.Active)
+}
+
+func TestPublisher_PublishWithoutMLKEMSeed(t *testing.T) {
+	ctx := context.Background()
+	pair := generateKeyPair(t, time.Time{})
+	c := newFakeClient(t)
+	k := keyring.New(keyring.KeyPair{Public: pair.Public, Private: keyring.StaticKey(pair.private)})
+
+	assert.NilError(t, keyring.NewPublisher(k, c).Publish(ctx))
+
+	keys := &lockboxv1.LockboxKeyList{}
+	assert.NilError(t, c.List(ctx, keys))
+	assert.Equal(t, len(keys.Items), 1)
+	assert.DeepEqual(t, keys.Items[0].Spec.PublicKey, pair.Public[:])
+	assert.Assert(t, keys.Items[0].Spec.HybridPublicKey == nil)
+}
+
+func TestPublisher_PublishExternalKey(t *testing.T) {
+	ctx := context.Background()
+	pair := generateKeyPair(t, time.Time{})
+	c := newFakeClient(t, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "example"}})
+	k := keyring.New(keyring.KeyPair{Public: pair.Public, Private: externalKey{pair.Private}})
+
+	assert.NilError(t, keyring.NewPublisher(k, c).Publish(ctx))
+
+	keys := &lockboxv1.LockboxKeyList{}
+	assert.NilError(t, c.List(ctx, keys))
+	assert.Equal(t, len(keys.Items), 1)
+	assert.Assert(t, !keys.Items[0].Spec.NamespaceKeys, "keys held outside memory don't derive namespace keys")
+}
+
+func TestPublisher_PublishNamespaceKeys(t *testing.T) {
+	ctx := context.Background()
+	older := generateKeyPair(t, time.Unix(1e9, 0))
+	newer := generateKeyPair(t, time.Unix(2e9, 0))
+	example := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "example"}}
+	c := newFakeClient(t, example)
+	k := keyring.New(older.KeyPair, newer.KeyPair)
+	publisher := keyring.NewPublisher(k, c)
+
+	assert.NilError(t, publisher.Publish(ctx))
+
+	keys := &lockboxv1.LockboxKeyList{}
+	assert.NilError(t, c.List(ctx, keys, client.MatchingLabels{lockboxv1.LockboxKeyNamespaceLabel: "example"}))
+	assert.Equal(t, len(keys.Items), 1, "only the active keypair's namespace keys are published")
+
+	nsPub, err := k.NamespacePublicKey("example")
+	assert.NilError(t, err)
+	nsHybrid, err := k.HybridPublicKey("example")
+	assert.NilError(t, err)
+	key := keys.Items[0]
+	assert.Equal(t, key.Name, keyring.Fingerprint(nsPub))
+	assert.DeepEqual(t, key.Spec.PublicKey, nsPub[:])
+	assert.DeepEqual(t, key.Spec.HybridPublicKey, nsHybrid)
+	assert.Equal(t, key.Spec.Namespace, "example")
+	assert.Assert(t, key.Spec.Active)
+
+	// Keys for deleted namespaces are removed.
+	assert.NilError(t, c.Delete(ctx, example))
+	assert.NilError(t, publisher.Publish(ctx))
+
+	assert.NilError(t, c.List(ctx, keys))
+	assert.Equal(t, len(keys.Items), 2)
+	for _, key := range keys.Items {
+		assert.Equal(t, key.Spec.Namespace, "")
+	}
+}
@@ -0,0 +1,157 @@
+package keyring
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// retryInterval is how long the Rotator waits after a failed rotation.
+	retryInterval = time.Minute
+
+	// inUseInterval is how long the Rotator waits before checking again whether an expired
+	// keypair still has Lockboxes sealed to it.
+	inUseInterval = time.Hour
+)
+
+// Rotator periodically generates a new keypair Secret for a SecretSource, and deletes
+// keypair Secrets superseded for longer than a retention period once no Lockboxes are
+// sealed to them.
+type Rotator struct {
+	source    *SecretSource
+	every     time.Duration
+	retention time.Duration
+
+	now func() time.Time
+}
+
+// NewRotator creates a Rotator generating a keypair every period. Superseded keypairs are
+// kept for the retention period, or indefinitely if the retention period is zero.
+func NewRotator(source *SecretSource, every, retention time.Duration) *Rotator {
+	return &Rotator{
+		source:    source,
+		every:     every,
+		retention: retention,
+		now:       time.Now,
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Only the leader rotates, so
+// replicas don't each create a keypair.
+func (r *Rotator) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable by rotating keypairs as they become due, until the
+// context is cancelled.
+func (r *Rotator) Start(ctx context.Context) error {
+	log := logf.FromContext(ctx).WithName("keyring-rotator")
+
+	for {
+		next, err := r.Rotate(ctx)
+		if err != nil {
+			log.Error(err, "unable to rotate keypairs")
+			next = retryInterval
+		}
+
+		timer := time.NewTimer(next)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// Rotate generates a new keypair if the newest keypair is older than the rotation period,
+// and deletes keypairs superseded for longer than the retention period, reloading the
+// keypairs if any changed. Expired keypairs are kept while any Lockbox is sealed to them,
+// as they're the only keys able to unlock it. It returns how long until a keypair is next
+// due for rotation or deletion.
+func (r *Rotator) Rotate(ctx context.Context) (time.Duration, error) {
+	log := logf.FromContext(ctx).WithName("keyring-rotator")
+
+	secrets := &corev1.SecretList{}
+	if err := r.source.client.List(ctx, secrets, client.InNamespace(r.source.namespace), client.HasLabels{SecretLabel}); err != nil {
+		return 0, err
+	}
+
+	now := r.now()
+	created := func(secret *corev1.Secret) time.Time {
+		if secret.CreationTimestamp.IsZero() {
+			return now
+		}
+		return secret.CreationTimestamp.Time
+	}
+
+	items := secrets.Items
+	sort.SliceStable(items, func(i, j int) bool {
+		return created(&items[i]).After(created(&items[j]))
+	})
+
+	changed := false
+	if len(items) == 0 || now.Sub(created(&items[0])) >= r.every {
+		secret, err := r.source.Create(ctx)
+		if err != nil {
+			return 0, err
+		}
+
+		items = append([]corev1.Secret{*secret}, items...)
+		changed = true
+	}
+
+	next := r.every - now.Sub(created(&items[0]))
+	var counts map[string]int
+	if r.retention > 0 {
+		for i := 1; i < len(items); i++ {
+			// A keypair is superseded once the next newest keypair is created.
+			expires := created(&items[i-1]).Add(r.retention)
+			if now.Before(expires) {
+				next = min(next, expires.Sub(now))
+				continue
+			}
+
+			if counts == nil {
+				var err error
+				if counts, err = r.source.keyring.SealedLockboxes(ctx, r.source.client); err != nil {
+					return 0, err
+				}
+			}
+
+			// Keypairs that can't be parsed, or aren't loaded yet, are assumed to be in use.
+			var count int
+			ok := false
+			if pub, _, _, err := KeyPairFromYAMLOrJSON(bytes.NewReader(items[i].Data[SecretKey])); err == nil {
+				count, ok = counts[hex.EncodeToString(pub[:])]
+			}
+			if !ok || count > 0 {
+				log.Info("keeping expired keypair that may still unlock lockboxes", "secret", items[i].Name, "lockboxes", count)
+				next = min(next, inUseInterval)
+				continue
+			}
+
+			err := r.source.client.Delete(ctx, &items[i], client.Preconditions{UID: &items[i].UID})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return 0, err
+			}
+			changed = true
+		}
+	}
+
+	if changed {
+		if err := r.source.Load(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	return next, nil
+}
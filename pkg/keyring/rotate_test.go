@@ -0,0 +1,109 @@
+package keyring_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/lockbox/pkg/keyring"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestRotator_Rotate(t *testing.T) {
+	ctx := context.Background()
+	day := 24 * time.Hour
+	now := time.Now()
+
+	expired := generateKeyPair(t, now.Add(-200*day))
+	retained := generateKeyPair(t, now.Add(-100*day))
+	active := generateKeyPair(t, now.Add(-91*day))
+
+	c := newFakeClient(t,
+		keypairSecret(t, "expired", "lockbox", expired),
+		keypairSecret(t, "retained", "lockbox", retained),
+		keypairSecret(t, "active", "lockbox", active),
+	)
+	k := keyring.New()
+	source := keyring.NewSecretSource(k, c, "lockbox")
+	assert.NilError(t, source.Load(ctx))
+
+	next, err := keyring.NewRotator(source, 90*day, 95*day).Rotate(ctx)
+	assert.NilError(t, err)
+	// The retained keypair was superseded 91 days ago, and expires in 4 days.
+	assert.Assert(t, next > 3*day && next <= 4*day, "next rotation in %s", next)
+
+	secrets := &corev1.SecretList{}
+	assert.NilError(t, c.List(ctx, secrets, client.InNamespace("lockbox")))
+	names := map[string]bool{}
+	for _, secret := range secrets.Items {
+		names[secret.Name] = true
+	}
+	assert.Equal(t, len(names), 3)
+	assert.Assert(t, !names["expired"])
+	assert.Assert(t, names["retained"])
+	assert.Assert(t, names["active"])
+
+	assert.Equal(t, len(k.KeyPairs()), 3)
+	_, ok := k.PrivateKey(expired.Public)
+	assert.Assert(t, !ok)
+	_, ok = k.PrivateKey(active.Public)
+	assert.Assert(t, ok)
+}
+
+func TestRotator_RotateInUse(t *testing.T) {
+	ctx := context.Background()
+	day := 24 * time.Hour
+	now := time.Now()
+
+	expired := generateKeyPair(t, now.Add(-200*day))
+	active := generateKeyPair(t, now.Add(-40*day))
+
+	namespacePub, _, err := keyring.DeriveNamespaceKey(expired.Private, "example")
+	assert.NilError(t, err)
+	c := newFakeClient(t,
+		keypairSecret(t, "expired", "lockbox", expired),
+		keypairSecret(t, "active", "lockbox", active),
+		sealedLockbox("example", "example", namespacePub),
+	)
+	source := keyring.NewSecretSource(keyring.New(), c, "lockbox")
+	assert.NilError(t, source.Load(ctx))
+
+	next, err := keyring.NewRotator(source, 90*day, 30*day).Rotate(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, next, time.Hour)
+
+	// The expired keypair is the only key able to unlock the Lockbox.
+	secrets := &corev1.SecretList{}
+	assert.NilError(t, c.List(ctx, secrets, client.InNamespace("lockbox")))
+	assert.Equal(t, len(secrets.Items), 2)
+
+	assert.NilError(t, c.Delete(ctx, sealedLockbox("example", "example", namespacePub)))
+	_, err = keyring.NewRotator(source, 90*day, 30*day).Rotate(ctx)
+	assert.NilError(t, err)
+	assert.NilError(t, c.List(ctx, secrets, client.InNamespace("lockbox")))
+	assert.Equal(t, len(secrets.Items), 1)
+	assert.Equal(t, secrets.Items[0].Name, "active")
+}
+
+func TestRotator_RotateNotDue(t *testing.T) {
+	ctx := context.Background()
+	day := 24 * time.Hour
+	now := time.Now()
+
+	c := newFakeClient(t,
+		keypairSecret(t, "superseded", "lockbox", generateKeyPair(t, now.Add(-20*day))),
+		keypairSecret(t, "active", "lockbox", generateKeyPair(t, now.Add(-10*day))),
+	)
+	source := keyring.NewSecretSource(keyring.New(), c, "lockbox")
+
+	next, err := keyring.NewRotator(source, 90*day, 30*day).Rotate(ctx)
+	assert.NilError(t, err)
+	// The superseded keypair expires 30 days after the active keypair was created.
+	assert.Assert(t, next > 19*day && next <= 20*day, "next rotation in %s", next)
+
+	secrets := &corev1.SecretList{}
+	assert.NilError(t, c.List(ctx, secrets, client.InNamespace("lockbox")))
+	assert.Equal(t, len(secrets.Items), 2)
+}
@@ -0,0 +1,187 @@
+package keyring
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/kevinburke/nacl"
+	"github.com/kevinburke/nacl/box"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// SecretLabel marks Secrets holding controller keypairs.
+	SecretLabel = "lockbox.k8s.cloudflare.com/keypair"
+
+	// SecretKey is the Secret data key holding the keypair, in the format read by
+	// KeyPairFromYAMLOrJSON.
+	SecretKey = "keypair.yaml"
+)
+
+// ErrNoKeyPairs is returned when no keypairs could be found.
+var ErrNoKeyPairs = errors.New("no keypairs found")
+
+// SecretSource loads keypairs into a Keyring from labelled Secrets in a single namespace.
+type SecretSource struct {
+	keyring   *Keyring
+	client    client.Client
+	namespace string
+}
+
+// NewSecretSource creates a SecretSource for the keypair Secrets in the namespace.
+func NewSecretSource(keyring *Keyring, c client.Client, namespace string) *SecretSource {
+	return &SecretSource{
+		keyring:   keyring,
+		client:    c,
+		namespace: namespace,
+	}
+}
+
+// Load replaces the Keyring's keypairs with those stored in keypair Secrets, using each
+// Secret's creation time as when the keypair was created. If any Secret is invalid, the
+// Keyring is left unchanged.
+func (s *SecretSource) Load(ctx context.Context) error {
+	secrets := &corev1.SecretList{}
+	if err := s.client.List(ctx, secrets, client.InNamespace(s.namespace), client.HasLabels{SecretLabel}); err != nil {
+		return err
+	}
+
+	if len(secrets.Items) == 0 {
+		return ErrNoKeyPairs
+	}
+
+	pairs := make([]KeyPair, 0, len(secrets.Items))
+	for _, secret := range secrets.Items {
+		pub, pri, mlkemSeed, err := KeyPairFromYAMLOrJSON(bytes.NewReader(secret.Data[SecretKey]))
+		if err != nil {
+			return fmt.Errorf("unable to parse keypair secret %s/%s: %w", secret.Namespace, secret.Name, err)
+		}
+
+		pairs = append(pairs, KeyPair{
+			Public:  pub,
+			Private: StaticHybridKey(pri, mlkemSeed),
+			Created: secret.CreationTimestamp.Time,
+		})
+	}
+
+	s.keyring.Replace(pairs...)
+	return nil
+}
+
+// Bootstrap generates and stores a new keypair if no keypair Secrets exist, returning
+// whether a keypair was created.
+func (s *SecretSource) Bootstrap(ctx context.Context) (bool, error) {
+	secrets := &metav1.PartialObjectMetadataList{}
+	secrets.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("SecretList"))
+	if err := s.client.List(ctx, secrets, client.InNamespace(s.namespace), client.HasLabels{SecretLabel}, client.Limit(1)); err != nil {
+		return false, err
+	}
+
+	if len(secrets.Items) > 0 {
+		return false, nil
+	}
+
+	_, err := s.Create(ctx)
+	return err == nil, err
+}
+
+// Bootstrapper is a manager.Runnable creating the first keypair Secret for a SecretSource,
+// if none exist, when the manager starts.
+type Bootstrapper struct {
+	source *SecretSource
+}
+
+// NewBootstrapper creates a Bootstrapper for the SecretSource.
+func NewBootstrapper(source *SecretSource) *Bootstrapper {
+	return &Bootstrapper{source: source}
+}
+
+// Start implements manager.Runnable by bootstrapping a keypair and loading it if created.
+func (b *Bootstrapper) Start(ctx context.Context) error {
+	created, err := b.source.Bootstrap(ctx)
+	if err != nil || !created {
+		return err
+	}
+
+	logf.FromContext(ctx).WithName("keyring").Info("generated new keypair", "namespace", b.source.namespace)
+	return b.source.Load(ctx)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Only the leader bootstraps,
+// so replicas starting together don't each create a keypair.
+func (b *Bootstrapper) NeedLeaderElection() bool {
+	return true
+}
+
+// Create generates a new keypair and stores it in a keypair Secret. The new keypair becomes
+// active the next time keypairs are loaded.
+func (s *SecretSource) Create(ctx context.Context) (*corev1.Secret, error) {
+	pub, pri, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	mlkemSeed, err := GenerateMLKEMSeed()
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := NewKeyPairSecret("lockbox-keypair-"+hex.EncodeToString(pub[:6]), s.namespace, pub, pri, mlkemSeed)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.client.Create(ctx, secret); err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+// NewKeyPairSecret returns a keypair Secret, which can be mounted as the controller's
+// keypair file or loaded by a SecretSource. The ML-KEM-768 seed may be nil, in which case
+// the keypair can't unlock hybrid Lockboxes.
+func NewKeyPairSecret(name, namespace string, pub, pri nacl.Key, mlkemSeed []byte) (*corev1.Secret, error) {
+	data, err := KeyPairToYAML(pub, pri, mlkemSeed)
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				SecretLabel: "true",
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			SecretKey: data,
+		},
+	}, nil
+}
+
+// Reconcile implements reconcile.Reconciler by reloading keypairs whenever a keypair Secret
+// changes. The request is ignored.
+func (s *SecretSource) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	return reconcile.Result{}, s.Load(ctx)
+}
+
+// IsKeyPairSecret reports whether the object is a keypair Secret loaded by this source,
+// for filtering watch events.
+func (s *SecretSource) IsKeyPairSecret(obj client.Object) bool {
+	_, ok := obj.GetLabels()[SecretLabel]
+	return ok && obj.GetNamespace() == s.namespace
+}
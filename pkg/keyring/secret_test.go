@@ -0,0 +1,133 @@
+package keyring_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"github.com/cloudflare/lockbox/pkg/keyring"
+	"github.com/kevinburke/nacl"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSecretSource_Bootstrap(t *testing.T) {
+	ctx := context.Background()
+	c := newFakeClient(t)
+	k := keyring.New()
+	source := keyring.NewSecretSource(k, c, "lockbox")
+
+	created, err := source.Bootstrap(ctx)
+	assert.NilError(t, err)
+	assert.Assert(t, created)
+
+	created, err = source.Bootstrap(ctx)
+	assert.NilError(t, err)
+	assert.Assert(t, !created)
+
+	assert.NilError(t, source.Load(ctx))
+	assert.Equal(t, len(k.KeyPairs()), 1)
+	assert.Assert(t, k.PublicKey() != nil)
+}
+
+func TestBootstrapper(t *testing.T) {
+	ctx := context.Background()
+	k := keyring.New()
+	b := keyring.NewBootstrapper(keyring.NewSecretSource(k, newFakeClient(t), "lockbox"))
+	assert.Assert(t, b.NeedLeaderElection())
+
+	assert.NilError(t, b.Start(ctx))
+	assert.Equal(t, len(k.KeyPairs()), 1)
+}
+
+func TestSecretSource_Load(t *testing.T) {
+	ctx := context.Background()
+	older := generateKeyPair(t, time.Unix(1e9, 0))
+	newer := generateKeyPair(t, time.Unix(2e9, 0))
+
+	c := newFakeClient(t,
+		keypairSecret(t, "older", "lockbox", older),
+		keypairSecret(t, "newer", "lockbox", newer),
+		keypairSecret(t, "elsewhere", "default", generateKeyPair(t, time.Unix(3e9, 0))),
+	)
+	k := keyring.New()
+	source := keyring.NewSecretSource(k, c, "lockbox")
+
+	assert.NilError(t, source.Load(ctx))
+	assert.Equal(t, len(k.KeyPairs()), 2)
+	assert.Assert(t, nacl.Verify32(k.PublicKey(), newer.Public))
+
+	invalid := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "invalid",
+			Namespace: "lockbox",
+			Labels:    map[string]string{keyring.SecretLabel: "true"},
+		},
+		Data: map[string][]byte{keyring.SecretKey: []byte("public: AAAA")},
+	}
+	assert.NilError(t, c.Create(ctx, invalid))
+
+	assert.ErrorContains(t, source.Load(ctx), "lockbox/invalid")
+	assert.Equal(t, len(k.KeyPairs()), 2)
+}
+
+func TestSecretSource_LoadEmpty(t *testing.T) {
+	source := keyring.NewSecretSource(keyring.New(), newFakeClient(t), "lockbox")
+	assert.ErrorIs(t, source.Load(context.Background()), keyring.ErrNoKeyPairs)
+}
+
+func newFakeClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	assert.NilError(t, corev1.AddToScheme(scheme))
+	assert.NilError(t, lockboxv1.AddToScheme(scheme))
+
+	return clientfake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		Build()
+}
+
+func keypairSecret(t *testing.T, name, namespace string, pair testKeyPair) *corev1.Secret {
+	t.Helper()
+
+	data, err := keyring.KeyPairToYAML(pair.Public, pair.private, pair.mlkemSeed)
+	assert.NilError(t, err)
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         namespace,
+			CreationTimestamp: metav1.NewTime(pair.Created),
+			Labels:            map[string]string{keyring.SecretLabel: "true"},
+		},
+		Data: map[string][]byte{keyring.SecretKey: data},
+	}
+}
+
+func TestNewKeyPairSecret(t *testing.T) {
+	ctx := context.Background()
+	pair := generateKeyPair(t, time.Time{})
+
+	secret, err := keyring.NewKeyPairSecret("keypair", "lockbox", pair.Public, pair.private, pair.mlkemSeed)
+	assert.NilError(t, err)
+	assert.Equal(t, secret.Kind, "Secret")
+	assert.Equal(t, secret.Labels[keyring.SecretLabel], "true")
+
+	c := newFakeClient(t, secret)
+	k := keyring.New()
+	assert.NilError(t, keyring.NewSecretSource(k, c, "lockbox").Load(ctx))
+	assert.Assert(t, nacl.Verify32(k.PublicKey(), pair.Public))
+
+	hybrid, err := k.HybridPublicKey("")
+	assert.NilError(t, err)
+	expected, err := lockboxv1.HybridPublicKey(pair.Public, pair.Private)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, hybrid, expected)
+}
@@ -0,0 +1,128 @@
+package keyring
+
+import (
+	"crypto/mlkem"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudflare/lockbox/pkg/shamir"
+	"github.com/kevinburke/nacl"
+	"github.com/kevinburke/nacl/scalarmult"
+)
+
+// sharePrefix identifies and versions the encoding of private key shares.
+const sharePrefix = "lockbox-share-v1"
+
+// Fingerprint returns a short identifier for a public key, to check that key shares and
+// keypair files belong together.
+func Fingerprint(pub nacl.Key) string {
+	sum := sha256.Sum256(pub[:])
+	return hex.EncodeToString(sum[:8])
+}
+
+// SplitPrivateKey splits the private key, and the ML-KEM-768 seed if set, into n shares,
+// any k of which reconstruct the keypair with CombinePrivateKey. Each share is a single
+// line of text recording the threshold, the public key's fingerprint, and a checksum to
+// catch transcription errors.
+func SplitPrivateKey(pub, pri nacl.Key, mlkemSeed []byte, n, k int) ([]string, error) {
+	if !nacl.Verify32(scalarmult.Base(pri), pub) {
+		return nil, fmt.Errorf("private key doesn't match public key")
+	}
+
+	shares, err := shamir.Split(append(append([]byte{}, pri[:]...), mlkemSeed...), n, k)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint := Fingerprint(pub)
+	encoded := make([]string, 0, len(shares))
+	for _, s := range shares {
+		body := fmt.Sprintf("%s:%d:%d:%s:%s", sharePrefix, k, s.X, fingerprint, hex.EncodeToString(s.Y))
+		encoded = append(encoded, body+":"+shareChecksum(body))
+	}
+
+	return encoded, nil
+}
+
+// CombinePrivateKey reconstructs a keypair, and its ML-KEM-768 seed if it was split, from
+// shares created by SplitPrivateKey.
+func CombinePrivateKey(encoded []string) (pub, pri nacl.Key, mlkemSeed []byte, err error) {
+	var threshold int
+	var fingerprint string
+	shares := make([]shamir.Share, 0, len(encoded))
+	for i, e := range encoded {
+		k, fp, s, err := parseShare(strings.TrimSpace(e))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("share %d: %w", i+1, err)
+		}
+
+		if i == 0 {
+			threshold, fingerprint = k, fp
+		} else if k != threshold || fp != fingerprint {
+			return nil, nil, nil, fmt.Errorf("share %d: belongs to a different key", i+1)
+		}
+		shares = append(shares, s)
+	}
+	if len(shares) < threshold {
+		return nil, nil, nil, fmt.Errorf("%d shares provided, %d are required", len(shares), threshold)
+	}
+
+	secret, err := shamir.Combine(shares)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(secret) != nacl.KeySize && len(secret) != nacl.KeySize+mlkem.SeedSize {
+		return nil, nil, nil, fmt.Errorf("incorrect private key length: %d, should be %d", len(secret), nacl.KeySize)
+	}
+
+	pri = new([nacl.KeySize]byte)
+	copy(pri[:], secret)
+	if len(secret) > nacl.KeySize {
+		mlkemSeed = secret[nacl.KeySize:]
+	}
+	pub = scalarmult.Base(pri)
+	if Fingerprint(pub) != fingerprint {
+		return nil, nil, nil, fmt.Errorf("reconstructed key doesn't match fingerprint %s", fingerprint)
+	}
+
+	return pub, pri, mlkemSeed, nil
+}
+
+func parseShare(e string) (threshold int, fingerprint string, share shamir.Share, err error) {
+	i := strings.LastIndexByte(e, ':')
+	if i < 0 {
+		return 0, "", share, fmt.Errorf("invalid share")
+	}
+	body, checksum := e[:i], e[i+1:]
+	if shareChecksum(body) != checksum {
+		return 0, "", share, fmt.Errorf("checksum mismatch")
+	}
+
+	fields := strings.Split(body, ":")
+	if len(fields) != 5 || fields[0] != sharePrefix {
+		return 0, "", share, fmt.Errorf("invalid share")
+	}
+
+	threshold, err = strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, "", share, fmt.Errorf("invalid threshold: %w", err)
+	}
+	x, err := strconv.ParseUint(fields[2], 10, 8)
+	if err != nil {
+		return 0, "", share, fmt.Errorf("invalid index: %w", err)
+	}
+	y, err := hex.DecodeString(fields[4])
+	if err != nil {
+		return 0, "", share, fmt.Errorf("invalid share value: %w", err)
+	}
+
+	return threshold, fields[3], shamir.Share{X: byte(x), Y: y}, nil
+}
+
+func shareChecksum(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:4])
+}
@@ -0,0 +1,72 @@
+package keyring_test
+
+import (
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/cloudflare/lockbox/pkg/keyring"
+	"github.com/kevinburke/nacl/box"
+	"gotest.tools/v3/assert"
+)
+
+func TestSplitCombinePrivateKey(t *testing.T) {
+	pub, pri, err := box.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+	mlkemSeed, err := keyring.GenerateMLKEMSeed()
+	assert.NilError(t, err)
+
+	shares, err := keyring.SplitPrivateKey(pub, pri, mlkemSeed, 5, 3)
+	assert.NilError(t, err)
+	assert.Equal(t, len(shares), 5)
+	for _, s := range shares {
+		assert.Assert(t, strings.Contains(s, keyring.Fingerprint(pub)))
+	}
+
+	type testCase struct {
+		shares  []string
+		wantErr string
+	}
+
+	run := func(t *testing.T, tc testCase) {
+		combinedPub, combinedPri, combinedSeed, err := keyring.CombinePrivateKey(tc.shares)
+		if tc.wantErr != "" {
+			assert.ErrorContains(t, err, tc.wantErr)
+			return
+		}
+		assert.NilError(t, err)
+		assert.DeepEqual(t, combinedPub, pub)
+		assert.DeepEqual(t, combinedPri, pri)
+		assert.DeepEqual(t, combinedSeed, mlkemSeed)
+	}
+
+	_, otherPri, err := box.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+	otherShares, err := keyring.SplitPrivateKey(pub, otherPri, nil, 5, 3)
+	assert.ErrorContains(t, err, "doesn't match")
+	assert.Assert(t, otherShares == nil)
+
+	// Flip a character of the share value.
+	typo := []byte(shares[1])
+	typo[len(typo)-20] ^= 1
+
+	testCases := map[string]testCase{
+		"quorum": {
+			shares: []string{shares[4], shares[0], shares[2]},
+		},
+		"below quorum": {
+			shares:  shares[:2],
+			wantErr: "2 shares provided, 3 are required",
+		},
+		"typo": {
+			shares:  []string{shares[0], string(typo), shares[2]},
+			wantErr: "share 2: checksum mismatch",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			run(t, tc)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package keyring
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"github.com/kevinburke/nacl"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SealedLockboxes counts the Lockboxes sealed to each of the Keyring's keypairs, either
+// directly or through one of its namespace keys, by the hex encoded public key of the
+// keypair. Every keypair is included, so a keypair missing from the result isn't held by
+// the Keyring. Lockboxes with several recipients are counted for each keypair able to
+// unlock them.
+func (k *Keyring) SealedLockboxes(ctx context.Context, c client.Reader) (map[string]int, error) {
+	lockboxes := &lockboxv1.LockboxList{}
+	if err := c.List(ctx, lockboxes); err != nil {
+		return nil, err
+	}
+
+	pairs := k.KeyPairs()
+	counts := make(map[string]int, len(pairs))
+	for _, pair := range pairs {
+		counts[hex.EncodeToString(pair.Public[:])] = 0
+	}
+
+	// Namespace public keys by keypair and namespace, as derivation may be slow.
+	type namespaceKey struct {
+		pair      int
+		namespace string
+	}
+	derived := map[namespaceKey]nacl.Key{}
+
+	for i := range lockboxes.Items {
+		lb := &lockboxes.Items[i]
+		for j, pair := range pairs {
+			key := namespaceKey{pair: j, namespace: lb.Namespace}
+			namespacePub, ok := derived[key]
+			if !ok {
+				var err error
+				namespacePub, err = k.namespacePublicKey(pair, lb.Namespace)
+				if errors.Is(err, ErrNoNamespaceKeys) {
+					namespacePub = nil
+				} else if err != nil {
+					return nil, err
+				}
+				derived[key] = namespacePub
+			}
+
+			if sealedTo(lb, pair.Public) || (namespacePub != nil && sealedTo(lb, namespacePub)) {
+				counts[hex.EncodeToString(pair.Public[:])]++
+			}
+		}
+	}
+
+	return counts, nil
+}
+
+// sealedTo reports whether the public key is one of the Lockbox's peers.
+func sealedTo(lb *lockboxv1.Lockbox, pub nacl.Key) bool {
+	for _, peer := range lb.Peers() {
+		if len(peer) == nacl.KeySize && nacl.Verify(peer, pub[:]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,259 @@
+// Package vault unwraps controller private keys encrypted by a Vault or OpenBao transit
+// secrets engine.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kevinburke/nacl"
+)
+
+// DefaultTokenPath is the default location of the Kubernetes service account token
+// used to log into Vault.
+const DefaultTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// renewBefore is how long before a login token expires that it's renewed, or replaced by
+// logging in again if it can't be renewed.
+const renewBefore = time.Minute
+
+// Config describes how to reach Vault and which transit key wraps the private key.
+type Config struct {
+	// Address is the Vault server's URL, such as https://vault.example.com:8200.
+	Address string
+
+	// Namespace is the Vault Enterprise namespace, if any.
+	Namespace string
+
+	// Token is a Vault token. If empty, a token is requested using Kubernetes auth.
+	Token string
+
+	// AuthMount is the path the Kubernetes auth method is mounted at, defaulting to
+	// "kubernetes".
+	AuthMount string
+
+	// Role is the Kubernetes auth role to log in as.
+	Role string
+
+	// TokenPath is the path of the service account token presented to Kubernetes auth,
+	// defaulting to DefaultTokenPath.
+	TokenPath string
+
+	// TransitMount is the path the transit secrets engine is mounted at, defaulting to
+	// "transit".
+	TransitMount string
+
+	// TransitKey is the name of the transit key wrapping the private key.
+	TransitKey string
+
+	// HTTPClient is used for requests to Vault, defaulting to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Transit unwraps private keys using a Vault transit key. It implements keyring.Unwrapper.
+// Tokens from Kubernetes auth are reused until they're due to expire, renewing them if
+// possible.
+type Transit struct {
+	cfg Config
+
+	mu    sync.Mutex
+	login *loginToken
+
+	now func() time.Time
+}
+
+// loginToken is a Vault token from Kubernetes auth and its lease.
+type loginToken struct {
+	token     string
+	renewable bool
+
+	// expires is when the token's lease ends, or zero if it never expires.
+	expires time.Time
+}
+
+// vaultAuth is the auth block of Vault's login and renewal responses.
+type vaultAuth struct {
+	ClientToken   string `json:"client_token"`
+	LeaseDuration int    `json:"lease_duration"`
+	Renewable     bool   `json:"renewable"`
+}
+
+// NewTransit creates a Transit unwrapper, filling in defaults for unset options.
+func NewTransit(cfg Config) *Transit {
+	if cfg.AuthMount == "" {
+		cfg.AuthMount = "kubernetes"
+	}
+	if cfg.TokenPath == "" {
+		cfg.TokenPath = DefaultTokenPath
+	}
+	if cfg.TransitMount == "" {
+		cfg.TransitMount = "transit"
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+
+	return &Transit{cfg: cfg, now: time.Now}
+}
+
+// Unwrap implements keyring.Unwrapper by decrypting the transit ciphertext, such as
+// "vault:v1:...", into a private key.
+func (t *Transit) Unwrap(ctx context.Context, wrapped string) (nacl.Key, error) {
+	token, err := t.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	path := fmt.Sprintf("%s/decrypt/%s", t.cfg.TransitMount, url.PathEscape(t.cfg.TransitKey))
+	if err := t.do(ctx, token, path, map[string]string{"ciphertext": wrapped}, &resp); err != nil {
+		return nil, err
+	}
+
+	plaintext, err := base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode transit plaintext: %w", err)
+	}
+	if len(plaintext) != nacl.KeySize {
+		return nil, fmt.Errorf("incorrect private key length: %d, should be %d", len(plaintext), nacl.KeySize)
+	}
+
+	pri := new([nacl.KeySize]byte)
+	copy(pri[:], plaintext)
+	return pri, nil
+}
+
+// token returns the configured Vault token, or a token from Kubernetes auth. Login tokens
+// are reused until they're due to expire, when they're renewed or replaced by logging in
+// again.
+func (t *Transit) token(ctx context.Context) (string, error) {
+	if t.cfg.Token != "" {
+		return t.cfg.Token, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if login := t.login; login != nil {
+		if login.expires.IsZero() || login.expires.Sub(now) > renewBefore {
+			return login.token, nil
+		}
+
+		// Tokens that can't be renewed, or have passed their max TTL, are replaced.
+		if login.renewable {
+			var resp struct {
+				Auth vaultAuth `json:"auth"`
+			}
+			if err := t.do(ctx, login.token, "auth/token/renew-self", map[string]string{}, &resp); err == nil && resp.Auth.LeaseDuration > 0 {
+				t.login = newLoginToken(login.token, resp.Auth, now)
+				if t.login.expires.Sub(now) > renewBefore {
+					return login.token, nil
+				}
+			}
+		}
+		t.login = nil
+	}
+
+	jwt, err := os.ReadFile(t.cfg.TokenPath)
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		Auth vaultAuth `json:"auth"`
+	}
+	body := map[string]string{
+		"role": t.cfg.Role,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	}
+	if err := t.do(ctx, "", fmt.Sprintf("auth/%s/login", t.cfg.AuthMount), body, &resp); err != nil {
+		return "", fmt.Errorf("unable to log into vault: %w", err)
+	}
+	if resp.Auth.ClientToken == "" {
+		return "", errors.New("vault login returned no token")
+	}
+
+	t.login = newLoginToken(resp.Auth.ClientToken, resp.Auth, now)
+	return t.login.token, nil
+}
+
+// newLoginToken returns the token with the lease described by auth, starting now.
+func newLoginToken(token string, auth vaultAuth, now time.Time) *loginToken {
+	login := &loginToken{token: token, renewable: auth.Renewable}
+	if auth.LeaseDuration > 0 {
+		login.expires = now.Add(time.Duration(auth.LeaseDuration) * time.Second)
+	}
+	return login
+}
+
+// Revoke revokes the token from Kubernetes auth, if any, so it can't be used once the
+// controller stops. Configured tokens aren't revoked.
+func (t *Transit) Revoke(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.login == nil {
+		return nil
+	}
+
+	err := t.do(ctx, t.login.token, "auth/token/revoke-self", map[string]string{}, nil)
+	t.login = nil
+	return err
+}
+
+// do sends a POST request with the JSON body to the Vault API path, decoding the JSON
+// response into out if it's non-nil.
+func (t *Transit) do(ctx context.Context, token, path string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	u := strings.TrimSuffix(t.cfg.Address, "/") + "/v1/" + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if t.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", t.cfg.Namespace)
+	}
+
+	resp, err := t.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		var apiErr struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&apiErr)
+		return fmt.Errorf("vault returned %s: %s", resp.Status, strings.Join(apiErr.Errors, "; "))
+	}
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,232 @@
+package vault_test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudflare/lockbox/pkg/keyring/vault"
+	"github.com/kevinburke/nacl"
+	"github.com/kevinburke/nacl/box"
+	"gotest.tools/v3/assert"
+)
+
+func TestTransit_Unwrap(t *testing.T) {
+	_, pri, err := box.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/k8s/login", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body["role"] != "lockbox" || body["jwt"] != "service-account-token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"auth":{"client_token":"vault-token"}}`))
+	})
+	mux.HandleFunc("/v1/transit/decrypt/lockbox", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+
+		var body map[string]string
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body["ciphertext"] != "vault:v1:wrapped" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":["invalid ciphertext"]}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]string{"plaintext": base64.StdEncoding.EncodeToString(pri[:])},
+		})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	assert.NilError(t, os.WriteFile(tokenPath, []byte("service-account-token\n"), 0o600))
+
+	transit := vault.NewTransit(vault.Config{
+		Address:    srv.URL,
+		AuthMount:  "k8s",
+		Role:       "lockbox",
+		TokenPath:  tokenPath,
+		TransitKey: "lockbox",
+	})
+
+	unwrapped, err := transit.Unwrap(context.Background(), "vault:v1:wrapped")
+	assert.NilError(t, err)
+	assert.Assert(t, nacl.Verify32(unwrapped, pri))
+
+	_, err = transit.Unwrap(context.Background(), "vault:v1:tampered")
+	assert.ErrorContains(t, err, "invalid ciphertext")
+
+	denied := vault.NewTransit(vault.Config{
+		Address:    srv.URL,
+		AuthMount:  "k8s",
+		Role:       "other",
+		TokenPath:  tokenPath,
+		TransitKey: "lockbox",
+	})
+	_, err = denied.Unwrap(context.Background(), "vault:v1:wrapped")
+	assert.ErrorContains(t, err, "permission denied")
+}
+
+func TestTransit_TokenLease(t *testing.T) {
+	_, pri, err := box.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+
+	type testCase struct {
+		name           string
+		login          string
+		expectedLogins int
+		expectedRenews int
+	}
+
+	run := func(t *testing.T, tc testCase) {
+		var logins, renews, revokes int
+		mux := http.NewServeMux()
+		mux.HandleFunc("/v1/auth/kubernetes/login", func(w http.ResponseWriter, r *http.Request) {
+			logins++
+			_, _ = w.Write([]byte(tc.login))
+		})
+		mux.HandleFunc("/v1/auth/token/renew-self", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, r.Header.Get("X-Vault-Token"), "vault-token")
+			renews++
+			_, _ = w.Write([]byte(`{"auth":{"client_token":"vault-token","lease_duration":3600,"renewable":true}}`))
+		})
+		mux.HandleFunc("/v1/auth/token/revoke-self", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, r.Header.Get("X-Vault-Token"), "vault-token")
+			revokes++
+			w.WriteHeader(http.StatusNoContent)
+		})
+		mux.HandleFunc("/v1/transit/decrypt/lockbox", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, r.Header.Get("X-Vault-Token"), "vault-token")
+			assert.Equal(t, r.Header.Get("X-Vault-Namespace"), "team")
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"data": map[string]string{"plaintext": base64.StdEncoding.EncodeToString(pri[:])},
+			})
+		})
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+
+		tokenPath := filepath.Join(t.TempDir(), "token")
+		assert.NilError(t, os.WriteFile(tokenPath, []byte("service-account-token"), 0o600))
+
+		transit := vault.NewTransit(vault.Config{
+			Address:    srv.URL,
+			Namespace:  "team",
+			Role:       "lockbox",
+			TokenPath:  tokenPath,
+			TransitKey: "lockbox",
+		})
+		for i := 0; i < 3; i++ {
+			_, err := transit.Unwrap(context.Background(), "vault:v1:wrapped")
+			assert.NilError(t, err)
+		}
+		assert.Equal(t, logins, tc.expectedLogins)
+		assert.Equal(t, renews, tc.expectedRenews)
+
+		assert.NilError(t, transit.Revoke(context.Background()))
+		assert.Equal(t, revokes, 1)
+	}
+
+	testCases := []testCase{
+		{
+			name:           "long lease",
+			login:          `{"auth":{"client_token":"vault-token","lease_duration":3600,"renewable":true}}`,
+			expectedLogins: 1,
+		},
+		{
+			name:           "renewable short lease",
+			login:          `{"auth":{"client_token":"vault-token","lease_duration":30,"renewable":true}}`,
+			expectedLogins: 1,
+			expectedRenews: 1,
+		},
+		{
+			name:           "unrenewable short lease",
+			login:          `{"auth":{"client_token":"vault-token","lease_duration":30,"renewable":false}}`,
+			expectedLogins: 3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			run(t, tc)
+		})
+	}
+}
+
+// TestTransit_DevServer runs against a Vault or OpenBao dev-mode server, such as one
+// started with `vault server -dev`. It requires VAULT_ADDR and VAULT_TOKEN to be set.
+func TestTransit_DevServer(t *testing.T) {
+	addr, token := os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN")
+	if addr == "" || token == "" {
+		t.Skip("VAULT_ADDR and VAULT_TOKEN not set")
+	}
+
+	// Mounting may fail if transit is already enabled, which is fine.
+	_ = vaultRequest(t, addr, token, "sys/mounts/transit", map[string]string{"type": "transit"}, nil)
+	assert.NilError(t, vaultRequest(t, addr, token, "transit/keys/lockbox-test", map[string]string{}, nil))
+
+	_, pri, err := box.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+
+	var encrypted struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	err = vaultRequest(t, addr, token, "transit/encrypt/lockbox-test", map[string]string{
+		"plaintext": base64.StdEncoding.EncodeToString(pri[:]),
+	}, &encrypted)
+	assert.NilError(t, err)
+
+	transit := vault.NewTransit(vault.Config{
+		Address:    addr,
+		Token:      token,
+		TransitKey: "lockbox-test",
+	})
+	unwrapped, err := transit.Unwrap(context.Background(), encrypted.Data.Ciphertext)
+	assert.NilError(t, err)
+	assert.Assert(t, nacl.Verify32(unwrapped, pri))
+}
+
+// vaultRequest sends a POST request to the Vault API, decoding the response into out if
+// it's non-nil.
+func vaultRequest(t *testing.T, addr, token, path string, body, out any) error {
+	t.Helper()
+
+	data, err := json.Marshal(body)
+	assert.NilError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(addr, "/")+"/v1/"+path, strings.NewReader(string(data)))
+	assert.NilError(t, err)
+	req.Header.Set("X-Vault-Token", token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("vault returned %s", resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,140 @@
+package keyring
+
+import (
+	"crypto/mlkem"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/kevinburke/nacl"
+	"sigs.k8s.io/yaml"
+)
+
+type kp struct {
+	Private []byte `json:"private,omitempty"`
+	Public  []byte `json:"public"`
+
+	// WrappedPrivate is the private key encrypted by a key management service.
+	WrappedPrivate string `json:"wrappedPrivate,omitempty"`
+
+	// MLKEMSeed is the seed of the ML-KEM-768 decapsulation key, generated independently
+	// of the private key.
+	MLKEMSeed []byte `json:"mlkemSeed,omitempty"`
+}
+
+// KeyPairFromYAMLOrJSON loads a public/private NaCL keypair from a YAML or JSON file.
+// The file contains the base64 encoded keys in "public" and "private" fields, and
+// optionally the base64 encoded seed of the ML-KEM-768 decapsulation key used for hybrid
+// Lockboxes in a "mlkemSeed" field. The seed is nil for keypairs without one.
+func KeyPairFromYAMLOrJSON(r io.Reader) (pub, pri nacl.Key, mlkemSeed []byte, err error) {
+	keypair, err := readKeyPair(r)
+	if err != nil {
+		return
+	}
+
+	if keypair.MLKEMSeed != nil && len(keypair.MLKEMSeed) != mlkem.SeedSize {
+		err = fmt.Errorf("incorrect ML-KEM seed length: %d, should be %d", len(keypair.MLKEMSeed), mlkem.SeedSize)
+		return
+	}
+
+	if len(keypair.Private) != 32 {
+		err = fmt.Errorf("incorrect private key length: %d, should be 32", len(keypair.Private))
+		return
+	}
+	if len(keypair.Public) != 32 {
+		err = fmt.Errorf("incorrect public key length: %d, should be 32", len(keypair.Public))
+		return
+	}
+
+	pub = new([nacl.KeySize]byte)
+	pri = new([nacl.KeySize]byte)
+
+	copy(pri[:], keypair.Private)
+	copy(pub[:], keypair.Public)
+	mlkemSeed = keypair.MLKEMSeed
+	return
+}
+
+// PrivateKeyFromYAMLOrJSON loads a NaCL private key from a YAML or JSON file in the format
+// read by KeyPairFromYAMLOrJSON, ignoring any public key.
+func PrivateKeyFromYAMLOrJSON(r io.Reader) (pri nacl.Key, err error) {
+	keypair, err := readKeyPair(r)
+	if err != nil {
+		return
+	}
+
+	if len(keypair.Private) != 32 {
+		err = fmt.Errorf("incorrect private key length: %d, should be 32", len(keypair.Private))
+		return
+	}
+
+	pri = new([nacl.KeySize]byte)
+	copy(pri[:], keypair.Private)
+	return
+}
+
+// WrappedKeyPairFromYAMLOrJSON loads a NaCL public key and wrapped private key from a YAML
+// or JSON file. The file contains the base64 encoded public key in a "public" field, and
+// the private key as wrapped by a key management service in a "wrappedPrivate" field.
+func WrappedKeyPairFromYAMLOrJSON(r io.Reader) (pub nacl.Key, wrapped string, err error) {
+	keypair, err := readKeyPair(r)
+	if err != nil {
+		return
+	}
+
+	if keypair.WrappedPrivate == "" {
+		err = errors.New("missing wrapped private key")
+		return
+	}
+	if len(keypair.Public) != 32 {
+		err = fmt.Errorf("incorrect public key length: %d, should be 32", len(keypair.Public))
+		return
+	}
+
+	pub = new([nacl.KeySize]byte)
+	copy(pub[:], keypair.Public)
+	return pub, keypair.WrappedPrivate, nil
+}
+
+func readKeyPair(r io.Reader) (kp, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return kp{}, err
+	}
+
+	keypair := kp{}
+	err = yaml.Unmarshal(data, &keypair, yaml.DisallowUnknownFields)
+	return keypair, err
+}
+
+// KeyPairToYAML serializes a public/private NaCL keypair and optional ML-KEM-768 seed to
+// YAML, in the format read by KeyPairFromYAMLOrJSON.
+func KeyPairToYAML(pub, pri nacl.Key, mlkemSeed []byte) ([]byte, error) {
+	return yaml.Marshal(kp{
+		Private:   pri[:],
+		Public:    pub[:],
+		MLKEMSeed: mlkemSeed,
+	})
+}
+
+// KeyPairToJSON serializes a public/private NaCL keypair and optional ML-KEM-768 seed to
+// JSON, in the format read by KeyPairFromYAMLOrJSON.
+func KeyPairToJSON(pub, pri nacl.Key, mlkemSeed []byte) ([]byte, error) {
+	return json.Marshal(kp{
+		Private:   pri[:],
+		Public:    pub[:],
+		MLKEMSeed: mlkemSeed,
+	})
+}
+
+// GenerateMLKEMSeed returns the seed of a new ML-KEM-768 decapsulation key, to store
+// alongside a keypair.
+func GenerateMLKEMSeed() ([]byte, error) {
+	dk, err := mlkem.GenerateKey768()
+	if err != nil {
+		return nil, err
+	}
+
+	return dk.Bytes(), nil
+}
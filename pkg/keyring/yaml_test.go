@@ -0,0 +1,62 @@
+package keyring_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/lockbox/pkg/keyring"
+	"github.com/kevinburke/nacl"
+	"gotest.tools/v3/assert"
+)
+
+func TestKeyPairRoundTrip(t *testing.T) {
+	pair := generateKeyPair(t, time.Time{})
+
+	tests := []struct {
+		name    string
+		marshal func(pub, pri nacl.Key, mlkemSeed []byte) ([]byte, error)
+	}{
+		{"yaml", keyring.KeyPairToYAML},
+		{"json", keyring.KeyPairToJSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.marshal(pair.Public, pair.private, pair.mlkemSeed)
+			assert.NilError(t, err)
+
+			pub, pri, mlkemSeed, err := keyring.KeyPairFromYAMLOrJSON(bytes.NewReader(data))
+			assert.NilError(t, err)
+			assert.Assert(t, nacl.Verify32(pub, pair.Public))
+			assert.Assert(t, nacl.Verify32(pri, pair.private))
+			assert.DeepEqual(t, mlkemSeed, pair.mlkemSeed)
+
+			data, err = tt.marshal(pair.Public, pair.private, nil)
+			assert.NilError(t, err)
+			_, _, mlkemSeed, err = keyring.KeyPairFromYAMLOrJSON(bytes.NewReader(data))
+			assert.NilError(t, err)
+			assert.Assert(t, mlkemSeed == nil)
+
+			pri, err = keyring.PrivateKeyFromYAMLOrJSON(bytes.NewReader(data))
+			assert.NilError(t, err)
+			assert.Assert(t, nacl.Verify32(pri, pair.private))
+		})
+	}
+}
+
+func TestKeyPairFromYAMLOrJSON_InvalidMLKEMSeed(t *testing.T) {
+	pair := generateKeyPair(t, time.Time{})
+	data, err := keyring.KeyPairToYAML(pair.Public, pair.private, pair.mlkemSeed[:32])
+	assert.NilError(t, err)
+
+	_, _, _, err = keyring.KeyPairFromYAMLOrJSON(bytes.NewReader(data))
+	assert.ErrorContains(t, err, "incorrect ML-KEM seed length: 32")
+}
+
+func TestPrivateKeyFromYAMLOrJSON(t *testing.T) {
+	_, err := keyring.PrivateKeyFromYAMLOrJSON(bytes.NewReader([]byte("public: AAAA")))
+	assert.ErrorContains(t, err, "incorrect private key length: 0")
+
+	_, err = keyring.PrivateKeyFromYAMLOrJSON(bytes.NewReader([]byte("unknown: AAAA")))
+	assert.ErrorContains(t, err, "unknown field")
+}
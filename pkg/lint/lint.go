@@ -0,0 +1,392 @@
+// Package lint checks Lockbox manifests for mistakes without any keys, so they can be
+// checked in CI before being applied.
+package lint
+
+import (
+	"bytes"
+	"crypto/mlkem"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"github.com/cloudflare/lockbox/pkg/keyring"
+	"github.com/kevinburke/nacl"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+// Rules reported in Findings.
+const (
+	RuleParse            = "parse"
+	RuleMetadata         = "metadata"
+	RuleKeyLength        = "key-length"
+	RulePeerAllowed      = "peer-allowed"
+	RuleDataKey          = "data-key"
+	RuleCiphertextLength = "ciphertext-length"
+	RuleAlgorithm        = "algorithm"
+	RuleSecretType       = "secret-type"
+	RulePath             = "path"
+)
+
+// Severity is how serious a Finding is.
+type Severity string
+
+const (
+	// SeverityError Findings would prevent the Lockbox from being unlocked.
+	SeverityError Severity = "error"
+
+	// SeverityWarning Findings can't be fully checked without keys.
+	SeverityWarning Severity = "warning"
+)
+
+const (
+	// sealedOverhead is the length added by sealing with secretbox or box: a nonce and
+	// an authenticator.
+	sealedOverhead = 24 + 16
+
+	// ageHeader starts every age file.
+	ageHeader = "age-encryption.org/v1\n"
+)
+
+// Finding is a problem found in a Lockbox manifest.
+type Finding struct {
+	File string `json:"file,omitempty"`
+
+	// Document is the index of the YAML document within the file.
+	Document int      `json:"document"`
+	Lockbox  string   `json:"lockbox,omitempty"`
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// Environment restricts the peer keys of Lockboxes in a directory.
+type Environment struct {
+	Name string `json:"name"`
+
+	// Directory contains the environment's Lockbox manifests, including in subdirectories.
+	Directory string `json:"directory"`
+
+	// Peers lists the allowed peer keys, by fingerprint or as hex. When the controller derives
+	// namespace keys, Lockboxes are sealed to each namespace's key rather than the master
+	// key, so every namespace's key must be listed.
+	Peers []string `json:"peers"`
+}
+
+// Config configures a Linter.
+type Config struct {
+	// PathPattern is the expected path of Lockbox manifests, relative to any directory.
+	// The {namespace} and {name} placeholders match the Lockbox's namespace and name.
+	PathPattern string `json:"pathPattern,omitempty"`
+
+	Environments []Environment `json:"environments,omitempty"`
+}
+
+// ParseConfig parses a YAML or JSON Config, rejecting unknown fields.
+func ParseConfig(b []byte) (Config, error) {
+	config := Config{}
+	err := yaml.Unmarshal(b, &config, yaml.DisallowUnknownFields)
+	return config, err
+}
+
+// Linter checks Lockbox manifests.
+type Linter struct {
+	config Config
+	path   *regexp.Regexp
+}
+
+// New creates a Linter with the Config.
+func New(config Config) (*Linter, error) {
+	l := &Linter{config: config}
+
+	if config.PathPattern != "" {
+		var err error
+		l.path, err = compilePathPattern(config.PathPattern)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return l, nil
+}
+
+// compilePathPattern converts a path pattern into a regular expression matching the end of
+// a slash separated path, with a named group for each placeholder.
+func compilePathPattern(pattern string) (*regexp.Regexp, error) {
+	var expr strings.Builder
+	expr.WriteString("(?:^|/)")
+
+	placeholders := regexp.MustCompile(`\{([a-z]+)\}`)
+	last := 0
+	for _, m := range placeholders.FindAllStringSubmatchIndex(pattern, -1) {
+		expr.WriteString(regexp.QuoteMeta(pattern[last:m[0]]))
+
+		switch name := pattern[m[2]:m[3]]; name {
+		case "namespace", "name":
+			fmt.Fprintf(&expr, "(?P<%s>[^/]+)", name)
+		default:
+			return nil, fmt.Errorf("unknown path pattern placeholder {%s}", name)
+		}
+		last = m[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(pattern[last:]))
+	expr.WriteString("$")
+
+	return regexp.Compile(expr.String())
+}
+
+// Lint checks a single document read from the file, which may be empty if the document
+// wasn't read from a file.
+func (l *Linter) Lint(file string, document int, doc []byte) []Finding {
+	var findings []Finding
+	report := func(rule string, severity Severity, lockbox, format string, args ...any) {
+		findings = append(findings, Finding{
+			File:     file,
+			Document: document,
+			Lockbox:  lockbox,
+			Rule:     rule,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	lb := &lockboxv1.Lockbox{}
+	if err := yaml.Unmarshal(doc, lb, yaml.DisallowUnknownFields); err != nil {
+		report(RuleParse, SeverityError, "", "invalid Lockbox: %s", err)
+		return findings
+	}
+	if lb.Kind != "Lockbox" || lb.APIVersion != lockboxv1.GroupVersion.String() {
+		report(RuleParse, SeverityError, "", "expected %s Lockbox, found %s %s", lockboxv1.GroupVersion, lb.APIVersion, lb.Kind)
+		return findings
+	}
+
+	id := lb.Namespace + "/" + lb.Name
+	errorf := func(rule, format string, args ...any) {
+		report(rule, SeverityError, id, format, args...)
+	}
+
+	if lb.Name == "" {
+		errorf(RuleMetadata, "missing name")
+	}
+	if lb.Namespace == "" {
+		errorf(RuleMetadata, "missing namespace")
+	}
+
+	lintKeys(lb, errorf)
+	l.lintPeer(file, lb, errorf)
+	lintDataKeys(lb, errorf)
+	lintCiphertexts(lb, errorf, func(format string, args ...any) {
+		report(RuleAlgorithm, SeverityWarning, id, format, args...)
+	})
+	lintSecretTypes(lb, errorf)
+	l.lintPath(file, lb, errorf)
+
+	return findings
+}
+
+type reportFunc func(rule, format string, args ...any)
+
+// lintKeys checks the length of each public key.
+func lintKeys(lb *lockboxv1.Lockbox, errorf reportFunc) {
+	// Algorithms such as age use a new sender key for each value, leaving Sender unset.
+	if len(lb.Spec.Sender) > 0 && len(lb.Spec.Sender) != nacl.KeySize {
+		errorf(RuleKeyLength, "sender key is %d bytes, should be %d", len(lb.Spec.Sender), nacl.KeySize)
+	}
+	if len(lb.Spec.Peer) != nacl.KeySize {
+		errorf(RuleKeyLength, "peer key is %d bytes, should be %d", len(lb.Spec.Peer), nacl.KeySize)
+	}
+
+	for i, r := range lb.Spec.Recipients {
+		if len(r.Sender) > 0 && len(r.Sender) != nacl.KeySize {
+			errorf(RuleKeyLength, "recipient %d sender key is %d bytes, should be %d", i, len(r.Sender), nacl.KeySize)
+		}
+		if len(r.Peer) != nacl.KeySize {
+			errorf(RuleKeyLength, "recipient %d peer key is %d bytes, should be %d", i, len(r.Peer), nacl.KeySize)
+		}
+	}
+}
+
+// lintPeer checks the peer key is allowed in the environment containing the file.
+func (l *Linter) lintPeer(file string, lb *lockboxv1.Lockbox, errorf reportFunc) {
+	env := l.environment(file)
+	if env == nil || len(lb.Spec.Peer) != nacl.KeySize {
+		return
+	}
+
+	peer := new([nacl.KeySize]byte)
+	copy(peer[:], lb.Spec.Peer)
+	fingerprint := keyring.Fingerprint(peer)
+
+	for _, allowed := range env.Peers {
+		if strings.EqualFold(allowed, fingerprint) || strings.EqualFold(allowed, hex.EncodeToString(peer[:])) {
+			return
+		}
+	}
+	errorf(RulePeerAllowed, "peer key %s isn't allowed in environment %s (namespace keys must be listed individually)", fingerprint, env.Name)
+}
+
+// environment returns the Environment with the longest directory containing the file, if
+// any.
+func (l *Linter) environment(file string) *Environment {
+	if file == "" {
+		return nil
+	}
+
+	var found *Environment
+	for i := range l.config.Environments {
+		env := &l.config.Environments[i]
+
+		rel, err := filepath.Rel(filepath.Clean(env.Directory), filepath.Clean(file))
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if found == nil || len(filepath.Clean(env.Directory)) > len(filepath.Clean(found.Directory)) {
+			found = env
+		}
+	}
+	return found
+}
+
+// lintDataKeys checks data and template keys are valid Secret keys.
+func lintDataKeys(lb *lockboxv1.Lockbox, errorf reportFunc) {
+	for _, key := range sortedKeys(lb.Spec.Data) {
+		for _, msg := range validation.IsConfigMapKey(key) {
+			errorf(RuleDataKey, "data key %q is invalid: %s", key, msg)
+		}
+	}
+
+	for _, key := range sortedKeys(lb.Spec.Template.Data) {
+		for _, msg := range validation.IsConfigMapKey(key) {
+			errorf(RuleDataKey, "template key %q is invalid: %s", key, msg)
+		}
+	}
+
+	for _, projection := range lb.Spec.Template.Projections {
+		for _, kp := range projection.Keys {
+			if kp.Name == "" {
+				continue
+			}
+			for _, msg := range validation.IsConfigMapKey(kp.Name) {
+				errorf(RuleDataKey, "projection %q key name %q is invalid: %s", projection.Name, kp.Name, msg)
+			}
+		}
+	}
+}
+
+// lintCiphertexts checks each sealed value is plausible for the Lockbox's algorithm.
+func lintCiphertexts(lb *lockboxv1.Lockbox, errorf reportFunc, warnf func(format string, args ...any)) {
+	algorithm := lb.Spec.Algorithm
+	if algorithm == "" {
+		algorithm = lockboxv1.AlgorithmNaClBox
+	}
+
+	var check func(sealed []byte) error
+	switch algorithm {
+	case lockboxv1.AlgorithmNaClBox, lockboxv1.AlgorithmX25519MLKEM768:
+		check = func(sealed []byte) error {
+			if len(sealed) < sealedOverhead {
+				return fmt.Errorf("is %d bytes, shorter than the %d byte nonce and authenticator", len(sealed), sealedOverhead)
+			}
+			return nil
+		}
+	case lockboxv1.AlgorithmAge:
+		check = func(sealed []byte) error {
+			if !bytes.HasPrefix(sealed, []byte(ageHeader)) {
+				return fmt.Errorf("isn't an age file")
+			}
+			return nil
+		}
+	default:
+		warnf("unknown algorithm %q, ciphertexts weren't checked", algorithm)
+		return
+	}
+
+	if err := check(lb.Spec.Namespace); err != nil {
+		errorf(RuleCiphertextLength, "sealed namespace %s", err)
+	}
+	for _, key := range sortedKeys(lb.Spec.Data) {
+		if err := check(lb.Spec.Data[key]); err != nil {
+			errorf(RuleCiphertextLength, "sealed value for key %q %s", key, err)
+		}
+	}
+
+	// Recipients hold the 32 byte data key, sealed with box or secretbox.
+	for i, r := range lb.Spec.Recipients {
+		if len(r.Key) != sealedOverhead+nacl.KeySize {
+			errorf(RuleCiphertextLength, "recipient %d sealed data key is %d bytes, should be %d", i, len(r.Key), sealedOverhead+nacl.KeySize)
+		}
+		if algorithm == lockboxv1.AlgorithmX25519MLKEM768 && len(r.Encapsulation) != mlkem.CiphertextSize768 {
+			errorf(RuleCiphertextLength, "recipient %d encapsulation is %d bytes, should be %d", i, len(r.Encapsulation), mlkem.CiphertextSize768)
+		}
+	}
+}
+
+// builtinSecretTypes are the Secret types defined by Kubernetes.
+var builtinSecretTypes = map[corev1.SecretType]bool{
+	corev1.SecretTypeOpaque:              true,
+	corev1.SecretTypeServiceAccountToken: true,
+	corev1.SecretTypeDockercfg:           true,
+	corev1.SecretTypeDockerConfigJson:    true,
+	corev1.SecretTypeBasicAuth:           true,
+	corev1.SecretTypeSSHAuth:             true,
+	corev1.SecretTypeTLS:                 true,
+	corev1.SecretTypeBootstrapToken:      true,
+}
+
+// lintSecretTypes checks the template and projection Secret types are built in, or
+// custom types outside of the kubernetes.io domain.
+func lintSecretTypes(lb *lockboxv1.Lockbox, errorf reportFunc) {
+	check := func(what string, t corev1.SecretType) {
+		switch {
+		case t == "" || builtinSecretTypes[t]:
+		case strings.HasPrefix(string(t), "kubernetes.io/") || strings.HasPrefix(string(t), "bootstrap.kubernetes.io/"):
+			errorf(RuleSecretType, "%s type %q isn't a Kubernetes Secret type", what, t)
+		default:
+			for _, msg := range validation.IsQualifiedName(string(t)) {
+				errorf(RuleSecretType, "%s type %q is invalid: %s", what, t, msg)
+			}
+		}
+	}
+
+	check("template", lb.Spec.Template.Type)
+	for _, projection := range lb.Spec.Template.Projections {
+		check(fmt.Sprintf("projection %q", projection.Name), projection.Type)
+	}
+}
+
+// lintPath checks the file's path matches the path pattern for the Lockbox's namespace
+// and name.
+func (l *Linter) lintPath(file string, lb *lockboxv1.Lockbox, errorf reportFunc) {
+	if l.path == nil || file == "" {
+		return
+	}
+
+	path := filepath.ToSlash(filepath.Clean(file))
+	m := l.path.FindStringSubmatch(path)
+	if m == nil {
+		errorf(RulePath, "path %s doesn't match pattern %s", path, l.config.PathPattern)
+		return
+	}
+
+	expected := map[string]string{"namespace": lb.Namespace, "name": lb.Name}
+	for i, name := range l.path.SubexpNames() {
+		if name == "" || m[i] == expected[name] {
+			continue
+		}
+		errorf(RulePath, "path %s is for %s %q, but the Lockbox's %s is %q", path, name, m[i], name, expected[name])
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,156 @@
+package lint_test
+
+import (
+	"crypto/rand"
+	"testing"
+
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"github.com/cloudflare/lockbox/pkg/keyring"
+	"github.com/cloudflare/lockbox/pkg/lint"
+	"github.com/kevinburke/nacl/box"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestLinter(t *testing.T) {
+	peerPub, _, err := box.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+	otherPub, _, err := box.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+	senderPub, senderPri, err := box.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+
+	newLockbox := func() *lockboxv1.Lockbox {
+		secret := corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "example"},
+			Data:       map[string][]byte{"test": []byte("value")},
+		}
+		lb := lockboxv1.NewFromSecret(secret, "default", peerPub, senderPub, senderPri)
+		lb.TypeMeta = metav1.TypeMeta{APIVersion: lockboxv1.GroupVersion.String(), Kind: "Lockbox"}
+		return lb
+	}
+
+	linter, err := lint.New(lint.Config{
+		PathPattern: "{namespace}/{name}.yaml",
+		Environments: []lint.Environment{
+			{Name: "production", Directory: "clusters/production", Peers: []string{keyring.Fingerprint(peerPub)}},
+			{Name: "staging", Directory: "clusters/staging", Peers: []string{keyring.Fingerprint(otherPub)}},
+		},
+	})
+	assert.NilError(t, err)
+
+	type testCase struct {
+		name     string
+		file     string
+		mutate   func(lb *lockboxv1.Lockbox)
+		doc      string
+		expected []string
+	}
+
+	run := func(t *testing.T, tc testCase) {
+		doc := []byte(tc.doc)
+		if tc.doc == "" {
+			lb := newLockbox()
+			if tc.mutate != nil {
+				tc.mutate(lb)
+			}
+			doc, err = yaml.Marshal(lb)
+			assert.NilError(t, err)
+		}
+
+		file := tc.file
+		if file == "" {
+			file = "clusters/production/default/example.yaml"
+		}
+
+		var rules []string
+		for _, f := range linter.Lint(file, 0, doc) {
+			rules = append(rules, f.Rule)
+		}
+		assert.DeepEqual(t, rules, tc.expected)
+	}
+
+	testCases := []testCase{
+		{
+			name: "valid",
+		},
+		{
+			name:     "not a lockbox",
+			doc:      "apiVersion: v1\nkind: Secret\n",
+			expected: []string{lint.RuleParse},
+		},
+		{
+			name:     "peer not allowed",
+			file:     "clusters/staging/default/example.yaml",
+			expected: []string{lint.RulePeerAllowed},
+		},
+		{
+			name:     "path mismatch",
+			file:     "clusters/production/other/example.yaml",
+			expected: []string{lint.RulePath},
+		},
+		{
+			name: "short keys",
+			mutate: func(lb *lockboxv1.Lockbox) {
+				lb.Spec.Sender = lb.Spec.Sender[:16]
+				lb.Spec.Peer = lb.Spec.Peer[:16]
+			},
+			expected: []string{lint.RuleKeyLength, lint.RuleKeyLength},
+		},
+		{
+			name: "invalid data key",
+			mutate: func(lb *lockboxv1.Lockbox) {
+				lb.Spec.Data["not/valid"] = lb.Spec.Data["test"]
+			},
+			expected: []string{lint.RuleDataKey},
+		},
+		{
+			name: "truncated ciphertext",
+			mutate: func(lb *lockboxv1.Lockbox) {
+				lb.Spec.Data["test"] = lb.Spec.Data["test"][:20]
+				lb.Spec.Recipients[0].Key = lb.Spec.Recipients[0].Key[:40]
+			},
+			expected: []string{lint.RuleCiphertextLength, lint.RuleCiphertextLength},
+		},
+		{
+			name: "unknown algorithm",
+			mutate: func(lb *lockboxv1.Lockbox) {
+				lb.Spec.Algorithm = "rot13"
+			},
+			expected: []string{lint.RuleAlgorithm},
+		},
+		{
+			name: "misspelled secret type",
+			mutate: func(lb *lockboxv1.Lockbox) {
+				lb.Spec.Template.Type = "kubernetes.io/tsl"
+			},
+			expected: []string{lint.RuleSecretType},
+		},
+		{
+			name: "custom secret type",
+			mutate: func(lb *lockboxv1.Lockbox) {
+				lb.Spec.Template.Type = "example.com/token"
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			run(t, tc)
+		})
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	config, err := lint.ParseConfig([]byte("pathPattern: '{namespace}/{name}.yaml'\nenvironments:\n- name: production\n  directory: clusters/production\n  peers: [ead486599b59fa7b]\n"))
+	assert.NilError(t, err)
+	assert.Equal(t, config.Environments[0].Peers[0], "ead486599b59fa7b")
+
+	_, err = lint.ParseConfig([]byte("unknown: true\n"))
+	assert.ErrorContains(t, err, "unknown field")
+
+	_, err = lint.New(lint.Config{PathPattern: "{cluster}/{name}.yaml"})
+	assert.ErrorContains(t, err, "unknown path pattern placeholder {cluster}")
+}
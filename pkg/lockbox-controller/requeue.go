@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"github.com/cloudflare/lockbox/pkg/keyring"
+	"github.com/cloudflare/lockbox/pkg/util/conditions"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// UnknownPeerKeyReason is the Ready condition reason set on Lockboxes locked for a
+// public key the controller doesn't hold.
+const UnknownPeerKeyReason = "UnknownPeerKey"
+
+// PeerKeyRequeuer requeues Lockboxes that failed with an unknown peer key each time the
+// controller's keypairs change, rather than waiting for the next sync period.
+type PeerKeyRequeuer struct {
+	client  client.Reader
+	updates <-chan struct{}
+	events  chan event.GenericEvent
+}
+
+// NewPeerKeyRequeuer creates a PeerKeyRequeuer for changes to the Keyring, listing Lockboxes
+// with the provided client.
+func NewPeerKeyRequeuer(c client.Reader, keys *keyring.Keyring) *PeerKeyRequeuer {
+	return &PeerKeyRequeuer{
+		client:  c,
+		updates: keys.Subscribe(),
+		events:  make(chan event.GenericEvent),
+	}
+}
+
+// Source returns the source of requeued Lockboxes, to be watched by the Lockbox controller.
+func (r *PeerKeyRequeuer) Source() source.Source {
+	return &source.Channel{Source: r.events}
+}
+
+// Start implements manager.Runnable by requeuing Lockboxes after each keypair change, until
+// the context is cancelled.
+func (r *PeerKeyRequeuer) Start(ctx context.Context) error {
+	log := logf.FromContext(ctx).WithName("peer-key-requeuer")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-r.updates:
+		}
+
+		lockboxes, err := r.unknownPeerKey(ctx)
+		if err != nil {
+			log.Error(err, "unable to list lockboxes")
+			continue
+		}
+
+		for i := range lockboxes {
+			select {
+			case <-ctx.Done():
+				return nil
+			case r.events <- event.GenericEvent{Object: &lockboxes[i]}:
+			}
+		}
+	}
+}
+
+// unknownPeerKey lists the Lockboxes whose Ready condition has the UnknownPeerKey reason.
+func (r *PeerKeyRequeuer) unknownPeerKey(ctx context.Context) ([]lockboxv1.Lockbox, error) {
+	list := &lockboxv1.LockboxList{}
+	if err := r.client.List(ctx, list); err != nil {
+		return nil, err
+	}
+
+	var lockboxes []lockboxv1.Lockbox
+	for _, lb := range list.Items {
+		ready := conditions.Get(&lb, lockboxv1.ReadyCondition)
+		if ready != nil && ready.Status == corev1.ConditionFalse && ready.Reason == UnknownPeerKeyReason {
+			lockboxes = append(lockboxes, lb)
+		}
+	}
+
+	return lockboxes, nil
+}
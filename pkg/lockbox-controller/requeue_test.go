@@ -0,0 +1,59 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"github.com/cloudflare/lockbox/pkg/keyring"
+	controller "github.com/cloudflare/lockbox/pkg/lockbox-controller"
+	"github.com/cloudflare/lockbox/pkg/util/conditions"
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestPeerKeyRequeuer(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NilError(t, lockboxv1.AddToScheme(scheme))
+
+	unknown := &lockboxv1.Lockbox{ObjectMeta: metav1.ObjectMeta{Name: "unknown", Namespace: "example"}}
+	conditions.Set(unknown, conditions.FalseCondition(lockboxv1.ReadyCondition, controller.UnknownPeerKeyReason, lockboxv1.ConditionSeverityError, "unknown peer key"))
+	invalid := &lockboxv1.Lockbox{ObjectMeta: metav1.ObjectMeta{Name: "invalid", Namespace: "example"}}
+	conditions.Set(invalid, conditions.FalseCondition(lockboxv1.ReadyCondition, "InvalidLockbox", lockboxv1.ConditionSeverityWarning, "invalid"))
+	ready := &lockboxv1.Lockbox{ObjectMeta: metav1.ObjectMeta{Name: "ready", Namespace: "example"}}
+	conditions.Set(ready, conditions.TrueCondition(lockboxv1.ReadyCondition))
+
+	client := clientfake.NewClientBuilder().
+		WithObjects(unknown, invalid, ready).
+		WithScheme(scheme).
+		Build()
+
+	keys := keyring.New()
+	requeuer := controller.NewPeerKeyRequeuer(client, keys)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer queue.ShutDown()
+	assert.NilError(t, requeuer.Source().Start(ctx, &handler.EnqueueRequestForObject{}, queue))
+	go func() { _ = requeuer.Start(ctx) }()
+
+	pubKey, priKey, err := loadKeypair(t, "6a42b9fc2b011fb88c01741483e3bffe455bdab1ae35d0bb53a3c00d406d8836", "252173f975f0a0ddb198a7e5958c074203a0e9f44275e0b840f95d456c4acc2e")
+	assert.NilError(t, err)
+	keys.Replace(keyring.KeyPair{Public: pubKey, Private: keyring.StaticKey(priKey)})
+
+	item, _ := queue.Get()
+	assert.Equal(t, item, reconcile.Request{NamespacedName: types.NamespacedName{Name: "unknown", Namespace: "example"}})
+	queue.Done(item)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, queue.Len(), 0)
+}
@@ -3,15 +3,22 @@ package controller
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"github.com/cloudflare/lockbox/pkg/keyring"
 	"github.com/cloudflare/lockbox/pkg/util/conditions"
 	"github.com/kevinburke/nacl"
-	"github.com/kevinburke/nacl/box"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -20,10 +27,13 @@ import (
 
 //go:generate controller-gen rbac:roleName=lockbox-controller paths=./. output:rbac:artifacts:config=../../deployment/rbac
 
-// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;patch;update
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;patch;update;delete
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 // +kubebuilder:rbac:groups="lockbox.k8s.cloudflare.com",resources=lockboxes,verbs=get;list;watch
 // +kubebuilder:rbac:groups="lockbox.k8s.cloudflare.com",resources=lockboxes/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="lockbox.k8s.cloudflare.com",resources=lockboxkeys,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups="coordination.k8s.io",resources=leases,verbs=get;list;watch;create;update;patch;delete
 
 const keySize = nacl.KeySize
 
@@ -32,10 +42,16 @@ type SecretReconcilerOption func(s *SecretReconciler)
 
 // SecretReconciler implements the reconciliation logic for Lockbox secrets.
 type SecretReconciler struct {
-	pubKey, priKey nacl.Key
+	keys *keyring.Keyring
 
 	client   client.Client
 	recorder record.EventRecorder
+	observer StatusObserver
+}
+
+// StatusObserver is notified each time the SecretReconciler updates a Lockbox's status.
+type StatusObserver interface {
+	ObserveStatus(lb *lockboxv1.Lockbox)
 }
 
 // NewSecretReconciler creates a reconciler controller for the provided keypair and options.
@@ -43,12 +59,15 @@ type SecretReconciler struct {
 // If not mutated by any options, the reconciler uses a noop API client and events recorder.
 func NewSecretReconciler(pubKey, priKey nacl.Key, options ...SecretReconcilerOption) *SecretReconciler {
 	sr := &SecretReconciler{
-		pubKey:   pubKey,
-		priKey:   priKey,
+		keys:     keyring.New(),
 		client:   clientfake.NewClientBuilder().Build(),
 		recorder: &record.FakeRecorder{},
 	}
 
+	if pubKey != nil && priKey != nil {
+		sr.keys.Replace(keyring.KeyPair{Public: pubKey, Private: keyring.StaticKey(priKey)})
+	}
+
 	for _, opt := range options {
 		opt(sr)
 	}
@@ -58,97 +77,445 @@ func NewSecretReconciler(pubKey, priKey nacl.Key, options ...SecretReconcilerOpt
 
 // Reconcile implements reconcile.Reconciler by ensuring Lockbox controlled Secrets are as described.
 func (s *SecretReconciler) Reconcile(ctx context.Context, lb *lockboxv1.Lockbox) (reconcile.Result, error) {
-	if len(lb.Spec.Sender) != keySize {
-		msg := fmt.Sprintf("invalid sender key length, got %d wanted %d", len(lb.Spec.Sender), keySize)
+	data, lerr := s.unlock(lb)
+	if lerr != nil {
+		return s.fail(ctx, lb, lerr)
+	}
 
-		s.recorder.Eventf(lb, "Warning", "InvalidKeyLength", msg)
-		conditions.Set(lb, conditions.FalseCondition(lockboxv1.ReadyCondition, "InvalidKeyLength", lockboxv1.ConditionSeverityError, msg))
-		_ = s.client.Status().Update(ctx, lb)
-		return reconcile.Result{}, fmt.Errorf("incorrect sender key length: %d, should be %d", len(lb.Spec.Sender), keySize)
+	// Check every projected Secret before writing any, so an invalid projection doesn't
+	// leave the Lockbox's Secrets partially updated.
+	projections, err := lb.SecretProjections()
+	if err != nil {
+		return s.fail(ctx, lb, &lockboxError{
+			err:      err,
+			reason:   "InvalidLockbox",
+			severity: lockboxv1.ConditionSeverityError,
+			message:  err.Error(),
+		})
+	}
+	for i := range projections {
+		if lerr := validateProjection(lb, &projections[i], data); lerr != nil {
+			return s.fail(ctx, lb, lerr)
+		}
 	}
-	if len(lb.Spec.Peer) != keySize {
-		msg := fmt.Sprintf("invalid peer key length, got %d wanted %d", len(lb.Spec.Peer), keySize)
 
-		s.recorder.Eventf(lb, "Warning", "InvalidKeyLength", msg)
-		conditions.Set(lb, conditions.FalseCondition(lockboxv1.ReadyCondition, "InvalidKeyLength", lockboxv1.ConditionSeverityError, msg))
-		_ = s.client.Status().Update(ctx, lb)
-		return reconcile.Result{}, fmt.Errorf("incorrect peer key length: %d, should be %d", len(lb.Spec.Peer), keySize)
+	names := make(map[string]struct{}, len(projections))
+	for i := range projections {
+		projection := &projections[i]
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      lb.SecretName(projection, data),
+				Namespace: lb.Namespace,
+			},
+		}
+		names[secret.Name] = struct{}{}
+
+		if immutableStrategy(projection) == lockboxv1.ImmutableStrategyRecreate {
+			err = s.recreateSecret(ctx, lb, projection, data, secret)
+		} else {
+			_, err = controllerutil.CreateOrPatch(
+				ctx,
+				s.client,
+				secret,
+				s.reconcileExisting(lb, projection, data, secret))
+		}
+
+		if err != nil {
+			conditions.Set(lb, conditions.FalseCondition(lockboxv1.ReadyCondition, "InvalidLockbox", lockboxv1.ConditionSeverityWarning, err.Error()))
+			s.updateStatus(ctx, lb)
+			return reconcile.Result{}, err
+		}
 	}
 
-	peerKey := new([keySize]byte)
-	copy(peerKey[:], lb.Spec.Peer)
+	requeueAfter, err := s.pruneSecrets(ctx, lb, projections, names)
+	if err != nil {
+		conditions.Set(lb, conditions.FalseCondition(lockboxv1.ReadyCondition, "PruneFailed", lockboxv1.ConditionSeverityWarning, err.Error()))
+		s.updateStatus(ctx, lb)
+		return reconcile.Result{}, err
+	}
 
-	if !nacl.Verify32(peerKey, s.pubKey) {
-		msg := fmt.Sprintf("lockbox has unknown peer key %q", base64.StdEncoding.EncodeToString(lb.Spec.Peer))
+	lb.Status.Secrets = make([]string, 0, len(names))
+	for name := range names {
+		lb.Status.Secrets = append(lb.Status.Secrets, name)
+	}
+	sort.Strings(lb.Status.Secrets)
 
-		s.recorder.Eventf(lb, "Warning", "UnknownPeerKey", msg)
-		conditions.Set(lb, conditions.FalseCondition(lockboxv1.ReadyCondition, "UnknownPeerKey", lockboxv1.ConditionSeverityError, msg))
-		_ = s.client.Status().Update(ctx, lb)
-		return reconcile.Result{}, fmt.Errorf("unknown peer key")
+	if message := checkSecretData(lb, projections, data); message != "" {
+		s.recorder.Event(lb, "Warning", "InvalidSecretData", message)
+		conditions.Set(lb, conditions.FalseCondition(lockboxv1.SecretDataValidCondition, "InvalidSecretData", lockboxv1.ConditionSeverityWarning, message))
+	} else {
+		conditions.Set(lb, conditions.TrueCondition(lockboxv1.SecretDataValidCondition))
 	}
 
-	sender := new([keySize]byte)
-	copy(sender[:], lb.Spec.Sender)
+	conditions.Set(lb, conditions.TrueCondition(lockboxv1.ReadyCondition))
+	s.updateStatus(ctx, lb)
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
+}
 
-	secret := &corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      lb.Name,
-			Namespace: lb.Namespace,
-		},
+// fail records why the Lockbox couldn't be reconciled as an event and in its Ready condition.
+func (s *SecretReconciler) fail(ctx context.Context, lb *lockboxv1.Lockbox, lerr *lockboxError) (reconcile.Result, error) {
+	event := lerr.event
+	if event == "" {
+		event = lerr.message
 	}
 
-	namespace, err := box.EasyOpen(lb.Spec.Namespace, sender, s.priKey)
+	s.recorder.Event(lb, "Warning", lerr.reason, event)
+	conditions.Set(lb, conditions.FalseCondition(lockboxv1.ReadyCondition, lerr.reason, lerr.severity, lerr.message))
+	s.updateStatus(ctx, lb)
+	return reconcile.Result{}, lerr.err
+}
+
+// lockboxError describes why a Lockbox couldn't be unlocked, for its Ready condition and
+// events.
+type lockboxError struct {
+	err      error
+	reason   string
+	severity lockboxv1.ConditionSeverity
+	message  string
+
+	// event is the event message, if different from the condition message.
+	event string
+}
+
+// unlock checks the Lockbox can be unlocked by the controller in its namespace, and returns
+// its unlocked data.
+func (s *SecretReconciler) unlock(lb *lockboxv1.Lockbox) (map[string][]byte, *lockboxError) {
+	opener, peerKey, lerr := s.opener(lb)
+	if lerr != nil {
+		return nil, lerr
+	}
+
+	if lerr := s.checkNamespace(lb, peerKey, opener); lerr != nil {
+		return nil, lerr
+	}
+
+	data, err := lb.UnlockShared(opener)
 	if err != nil {
-		msg := fmt.Sprintf("unable to open lockbox with peer key %q", base64.StdEncoding.EncodeToString(lb.Spec.Peer))
+		lerr := &lockboxError{
+			err:      err,
+			reason:   "InvalidLockbox",
+			severity: lockboxv1.ConditionSeverityWarning,
+			message:  err.Error(),
+			event:    "lockbox could not be unlocked",
+		}
 
-		s.recorder.Eventf(lb, "Warning", "InvalidLockbox", msg)
-		conditions.Set(lb, conditions.FalseCondition(lockboxv1.ReadyCondition, "InvalidLockbox", lockboxv1.ConditionSeverityError, msg))
-		_ = s.client.Status().Update(ctx, lb)
-		return reconcile.Result{}, err
+		var keyErr decryptSecretKeyErrorer
+		var tplErr renderTemplateErrorer
+		switch {
+		case errors.Is(err, lockboxv1.ErrNoDecapsulationKey):
+			lerr.reason = "KeyProviderError"
+			lerr.message = fmt.Sprintf("keypair for peer key %q has no ML-KEM-768 decapsulation key", base64.StdEncoding.EncodeToString(peerKey[:]))
+		case errors.As(err, &keyErr):
+			lerr.event = fmt.Sprintf("lockbox contained key %q that could not be unlocked", keyErr.SecretKey())
+		case errors.As(err, &tplErr):
+			lerr.reason, lerr.severity = "TemplateError", lockboxv1.ConditionSeverityError
+			lerr.event = fmt.Sprintf("lockbox template %q could not be rendered: %s", tplErr.TemplateKey(), err)
+		}
+		return nil, lerr
 	}
 
-	if string(namespace) != lb.Namespace {
-		msg := fmt.Sprintf("locked for namespace %q, found in namespace %s", namespace, lb.Namespace)
+	return data, nil
+}
 
-		s.recorder.Eventf(lb, "Warning", "InvalidNamespace", msg)
-		conditions.Set(lb, conditions.FalseCondition(lockboxv1.ReadyCondition, "InvalidNamespace", lockboxv1.ConditionSeverityWarning, msg))
-		_ = s.client.Status().Update(ctx, lb)
-		return reconcile.Result{}, fmt.Errorf("incorrect namespace: %s, should be %s", namespace, lb.Namespace)
+// opener checks the Lockbox's keys, and returns an Opener for the first recipient the
+// controller holds a key for, along with that recipient's key.
+func (s *SecretReconciler) opener(lb *lockboxv1.Lockbox) (lockboxv1.Opener, nacl.Key, *lockboxError) {
+	// Algorithms such as age use a new sender key for each value, leaving Sender unset.
+	if len(lb.Spec.Sender) > 0 && len(lb.Spec.Sender) != keySize {
+		return nil, nil, &lockboxError{
+			err:      fmt.Errorf("incorrect sender key length: %d, should be %d", len(lb.Spec.Sender), keySize),
+			reason:   "InvalidKeyLength",
+			severity: lockboxv1.ConditionSeverityError,
+			message:  fmt.Sprintf("invalid sender key length, got %d wanted %d", len(lb.Spec.Sender), keySize),
+		}
+	}
+	if len(lb.Spec.Peer) != keySize {
+		return nil, nil, &lockboxError{
+			err:      fmt.Errorf("incorrect peer key length: %d, should be %d", len(lb.Spec.Peer), keySize),
+			reason:   "InvalidKeyLength",
+			severity: lockboxv1.ConditionSeverityError,
+			message:  fmt.Sprintf("invalid peer key length, got %d wanted %d", len(lb.Spec.Peer), keySize),
+		}
 	}
 
-	_, err = controllerutil.CreateOrPatch(
-		ctx,
-		s.client,
-		secret,
-		s.reconcileExisting(lb, sender, secret))
+	// Find the first recipient the controller holds a key for.
+	var provider keyring.KeyProvider
+	peerKey := new([keySize]byte)
+	for _, peer := range lb.Peers() {
+		if len(peer) != keySize {
+			continue
+		}
+		copy(peerKey[:], peer)
+
+		var ok bool
+		var err error
+		provider, ok, err = s.keys.NamespacePrivateKey(peerKey, lb.Namespace)
+		if err != nil {
+			return nil, nil, &lockboxError{
+				err:      err,
+				reason:   "KeyProviderError",
+				severity: lockboxv1.ConditionSeverityError,
+				message:  fmt.Sprintf("unable to derive namespace key: %s", err),
+			}
+		}
+		if ok {
+			break
+		}
+	}
+	if provider == nil {
+		return nil, nil, &lockboxError{
+			err:      fmt.Errorf("unknown peer key"),
+			reason:   UnknownPeerKeyReason,
+			severity: lockboxv1.ConditionSeverityError,
+			message:  fmt.Sprintf("lockbox has unknown peer key %q", base64.StdEncoding.EncodeToString(lb.Spec.Peer)),
+		}
+	}
 
+	opener, err := lb.Opener(peerKey, provider)
 	if err != nil {
-		conditions.Set(lb, conditions.FalseCondition(lockboxv1.ReadyCondition, "InvalidLockbox", lockboxv1.ConditionSeverityWarning, err.Error()))
-		_ = s.client.Status().Update(ctx, lb)
-		return reconcile.Result{}, err
+		lerr := &lockboxError{
+			err:      err,
+			reason:   "InvalidLockbox",
+			severity: lockboxv1.ConditionSeverityError,
+			message:  fmt.Sprintf("unable to open lockbox with peer key %q: %s", base64.StdEncoding.EncodeToString(peerKey[:]), err),
+		}
+
+		var algErr unsupportedAlgorithmErrorer
+		var lenErr invalidKeyLengthErrorer
+		var keyErr keyAgreementErrorer
+		switch {
+		case errors.As(err, &algErr):
+			lerr.reason = "UnsupportedAlgorithm"
+			lerr.message = fmt.Sprintf("lockbox uses unsupported algorithm %q", algErr.Algorithm())
+		case errors.As(err, &lenErr):
+			lerr.reason = "InvalidKeyLength"
+			lerr.message = fmt.Sprintf("invalid %s key length for peer key %q", lenErr.InvalidKeyLength(), base64.StdEncoding.EncodeToString(peerKey[:]))
+		case errors.As(err, &keyErr):
+			lerr.reason = "KeyProviderError"
+			lerr.message = fmt.Sprintf("unable to compute shared key with peer key %q: %s", base64.StdEncoding.EncodeToString(peerKey[:]), err)
+		}
+		return nil, nil, lerr
 	}
 
-	conditions.Set(lb, conditions.TrueCondition(lockboxv1.ReadyCondition))
-	_ = s.client.Status().Update(ctx, lb)
-	return reconcile.Result{}, nil
+	return opener, peerKey, nil
+}
+
+// checkNamespace checks the Lockbox was locked for the namespace it's in.
+func (s *SecretReconciler) checkNamespace(lb *lockboxv1.Lockbox, peerKey nacl.Key, opener lockboxv1.Opener) *lockboxError {
+	namespace, err := lb.OpenNamespace(opener)
+	if err != nil {
+		return &lockboxError{
+			err:      err,
+			reason:   "InvalidLockbox",
+			severity: lockboxv1.ConditionSeverityError,
+			message:  fmt.Sprintf("unable to open lockbox with peer key %q", base64.StdEncoding.EncodeToString(peerKey[:])),
+		}
+	}
+
+	if namespace != lb.Namespace {
+		return &lockboxError{
+			err:      fmt.Errorf("incorrect namespace: %s, should be %s", namespace, lb.Namespace),
+			reason:   "InvalidNamespace",
+			severity: lockboxv1.ConditionSeverityWarning,
+			message:  fmt.Sprintf("locked for namespace %q, found in namespace %s", namespace, lb.Namespace),
+		}
+	}
+
+	return nil
+}
+
+// validateProjection checks the projection selects keys present in the unlocked data, and
+// that the resulting Secret has the keys and annotations the API server requires for its
+// type.
+func validateProjection(lb *lockboxv1.Lockbox, projection *lockboxv1.LockboxSecretProjection, data map[string][]byte) *lockboxError {
+	secret := &corev1.Secret{}
+	if err := projection.ProjectInto(secret, data); err != nil {
+		return &lockboxError{
+			err:      err,
+			reason:   "InvalidLockbox",
+			severity: lockboxv1.ConditionSeverityWarning,
+			message:  err.Error(),
+		}
+	}
+
+	if missing := lockboxv1.MissingSecretKeys(secret.Type, secret.Data); len(missing) > 0 {
+		err := fmt.Errorf("%s Secret is missing keys: %s", secret.Type, strings.Join(missing, ", "))
+		return &lockboxError{
+			err:      err,
+			reason:   "InvalidSecretData",
+			severity: lockboxv1.ConditionSeverityError,
+			message:  fmt.Sprintf("secret %q has invalid data: %s", lb.SecretName(projection, data), err),
+		}
+	}
+	if missing := lockboxv1.MissingSecretAnnotations(secret.Type, secret.Annotations); len(missing) > 0 {
+		err := fmt.Errorf("%s Secret is missing annotations: %s", secret.Type, strings.Join(missing, ", "))
+		return &lockboxError{
+			err:      err,
+			reason:   "InvalidSecretData",
+			severity: lockboxv1.ConditionSeverityError,
+			message:  fmt.Sprintf("secret %q is invalid: %s", lb.SecretName(projection, data), err),
+		}
+	}
+
+	return nil
+}
+
+// checkSecretData checks each projected Secret's data is usable for its type, returning a
+// message describing the first that isn't. Such Secrets are still written, as the API server
+// accepts them and workloads may not need every value to be valid.
+func checkSecretData(lb *lockboxv1.Lockbox, projections []lockboxv1.LockboxSecretProjection, data map[string][]byte) string {
+	for i := range projections {
+		projection := &projections[i]
+
+		secret := &corev1.Secret{}
+		if err := projection.ProjectInto(secret, data); err != nil {
+			continue
+		}
+		if err := lockboxv1.ValidateSecret(secret); err != nil {
+			return fmt.Sprintf("secret %q has invalid data: %s", lb.SecretName(projection, data), err)
+		}
+	}
+
+	return ""
 }
 
 // reconcileExisting returns a function suitable for controllerutil.CreateOrUpdate that mutates a Secret object
-// to reflect the desired state.
-func (s *SecretReconciler) reconcileExisting(lb *lockboxv1.Lockbox, sender nacl.Key, secret *corev1.Secret) func() error {
+// to reflect the desired state of a projection.
+func (s *SecretReconciler) reconcileExisting(lb *lockboxv1.Lockbox, projection *lockboxv1.LockboxSecretProjection, data map[string][]byte, secret *corev1.Secret) func() error {
 	return func() error {
 		if err := controllerutil.SetControllerReference(lb, secret, s.client.Scheme()); err != nil {
-			switch err := err.(type) {
-			case decryptSecretKeyErrorer:
-				s.recorder.Eventf(lb, "Warning", "InvalidLockbox", "lockbox contained key %q that could not be unlocked", err.SecretKey())
-			default:
-				s.recorder.Eventf(lb, "Warning", "InvalidLockbox", "lockbox could not be unlocked")
-			}
+			return err
+		}
 
+		if err := projection.ProjectInto(secret, data); err != nil {
 			return err
 		}
 
-		return lb.UnlockInto(secret, s.priKey)
+		if immutableStrategy(projection) == lockboxv1.ImmutableStrategyHash {
+			labels := make(map[string]string, len(secret.Labels)+1)
+			for k, v := range secret.Labels {
+				labels[k] = v
+			}
+			labels[lockboxv1.ProjectionLabel] = projection.Name
+			secret.Labels = labels
+		}
+
+		return nil
+	}
+}
+
+// recreateSecret reconciles an immutable Secret using the Recreate strategy. If the existing
+// Secret's contents differ from the desired state, it's deleted and created again.
+func (s *SecretReconciler) recreateSecret(ctx context.Context, lb *lockboxv1.Lockbox, projection *lockboxv1.LockboxSecretProjection, data map[string][]byte, secret *corev1.Secret) error {
+	mutate := s.reconcileExisting(lb, projection, data, secret)
+
+	existing := &corev1.Secret{}
+	err := s.client.Get(ctx, client.ObjectKeyFromObject(secret), existing)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	desired := &corev1.Secret{}
+	if err := projection.ProjectInto(desired, data); err != nil {
+		return err
+	}
+
+	unchanged := existing.Type == desired.Type &&
+		ptr.Deref(existing.Immutable, false) &&
+		equality.Semantic.DeepEqual(existing.Data, desired.Data)
+
+	// Unchanged or foreign Secrets are left to CreateOrPatch, which updates metadata
+	// or reports the ownership conflict.
+	if apierrors.IsNotFound(err) || unchanged || !metav1.IsControlledBy(existing, lb) {
+		_, err = controllerutil.CreateOrPatch(ctx, s.client, secret, mutate)
+		return err
+	}
+
+	if err := s.client.Delete(ctx, existing, client.Preconditions{UID: &existing.UID}); client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	s.recorder.Eventf(lb, "Normal", "SecretRecreated", "recreated immutable secret %q with updated data", secret.Name)
+
+	if err := mutate(); err != nil {
+		return err
+	}
+	return s.client.Create(ctx, secret)
+}
+
+// pruneSecrets deletes Secrets controlled by the Lockbox that no longer match one of its
+// projections, such as after a projection is renamed or removed, or its data changes under
+// the Hash strategy. Superseded Secrets are retained for the projection's retention period,
+// and the duration until the next one expires is returned.
+func (s *SecretReconciler) pruneSecrets(ctx context.Context, lb *lockboxv1.Lockbox, projections []lockboxv1.LockboxSecretProjection, names map[string]struct{}) (time.Duration, error) {
+	secrets := &corev1.SecretList{}
+	if err := s.client.List(ctx, secrets, client.InNamespace(lb.Namespace)); err != nil {
+		return 0, err
+	}
+
+	retention := make(map[string]time.Duration, len(projections))
+	for _, projection := range projections {
+		if immutableStrategy(&projection) == lockboxv1.ImmutableStrategyHash && projection.Immutable.RetentionPeriod != nil {
+			retention[projection.Name] = projection.Immutable.RetentionPeriod.Duration
+		}
+	}
+
+	var requeueAfter time.Duration
+	now := time.Now()
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if _, ok := names[secret.Name]; ok || !metav1.IsControlledBy(secret, lb) {
+			continue
+		}
+
+		if period, ok := retention[secret.Labels[lockboxv1.ProjectionLabel]]; ok {
+			superseded, err := time.Parse(time.RFC3339, secret.Annotations[lockboxv1.SupersededAnnotation])
+			if err != nil {
+				superseded = now
+				patch := client.MergeFrom(secret.DeepCopy())
+				metav1.SetMetaDataAnnotation(&secret.ObjectMeta, lockboxv1.SupersededAnnotation, now.UTC().Format(time.RFC3339))
+				if err := s.client.Patch(ctx, secret, patch); err != nil {
+					return 0, err
+				}
+			}
+
+			if remaining := superseded.Add(period).Sub(now); remaining > 0 {
+				if requeueAfter == 0 || remaining < requeueAfter {
+					requeueAfter = remaining
+				}
+				continue
+			}
+		}
+
+		if err := s.client.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+			return 0, err
+		}
+		s.recorder.Eventf(lb, "Normal", "SecretPruned", "deleted secret %q no longer projected by lockbox", secret.Name)
+	}
+
+	return requeueAfter, nil
+}
+
+// immutableStrategy returns the strategy used to replace the projection's Secret, or the
+// empty string if the Secret is mutable.
+func immutableStrategy(projection *lockboxv1.LockboxSecretProjection) lockboxv1.ImmutableStrategy {
+	switch {
+	case projection.Immutable == nil:
+		return ""
+	case projection.Immutable.Strategy == "":
+		return lockboxv1.ImmutableStrategyHash
+	default:
+		return projection.Immutable.Strategy
+	}
+}
+
+// updateStatus persists the Lockbox's status and notifies the StatusObserver, if any.
+// Errors are ignored, as the status is updated again on the next reconcile.
+func (s *SecretReconciler) updateStatus(ctx context.Context, lb *lockboxv1.Lockbox) {
+	_ = s.client.Status().Update(ctx, lb)
+
+	if s.observer != nil {
+		s.observer.ObserveStatus(lb)
 	}
 }
 
@@ -166,8 +533,48 @@ func WithClient(c client.Client) SecretReconcilerOption {
 	}
 }
 
+// WithKeyring sets the Keyring used to find the keypair for each Lockbox's peer key,
+// replacing the keypair passed to NewSecretReconciler.
+func WithKeyring(k *keyring.Keyring) SecretReconcilerOption {
+	return func(s *SecretReconciler) {
+		s.keys = k
+	}
+}
+
+// WithStatusObserver sets the StatusObserver notified of Lockbox status updates.
+func WithStatusObserver(o StatusObserver) SecretReconcilerOption {
+	return func(s *SecretReconciler) {
+		s.observer = o
+	}
+}
+
 // decryptSecretKeyErrorer matches the unexported error type, to
 // fetch the secret data key that triggered the error.
 type decryptSecretKeyErrorer interface {
 	SecretKey() string
 }
+
+// unsupportedAlgorithmErrorer matches the unexported error type, to
+// fetch the algorithm without a registered decryptor.
+type unsupportedAlgorithmErrorer interface {
+	Algorithm() string
+}
+
+// invalidKeyLengthErrorer matches the unexported error type, to
+// fetch the name of the key with the wrong length.
+type invalidKeyLengthErrorer interface {
+	InvalidKeyLength() string
+}
+
+// keyAgreementErrorer matches the unexported error type, to
+// distinguish key provider failures from invalid Lockboxes.
+type keyAgreementErrorer interface {
+	KeyAgreement() bool
+}
+
+// renderTemplateErrorer matches the unexported error type, to
+// fetch the template data key that triggered the error.
+type renderTemplateErrorer interface {
+	error
+	TemplateKey() string
+}
@@ -2,11 +2,16 @@ package controller_test
 
 import (
 	"context"
+	"crypto/rand"
 	"testing"
+	"time"
 
 	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"github.com/cloudflare/lockbox/pkg/keyring"
 	controller "github.com/cloudflare/lockbox/pkg/lockbox-controller"
+	"github.com/cloudflare/lockbox/pkg/util/conditions"
 	"github.com/kevinburke/nacl"
+	"github.com/kevinburke/nacl/box"
 	"gotest.tools/v3/assert"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -26,6 +31,10 @@ func TestSecretReconciler(t *testing.T) {
 		resources   []client.Object
 		expected    *corev1.Secret
 		expectedErr string
+		// expectedReason, if set, is the Ready condition reason set on the Lockbox
+		expectedReason string
+		// expectedWarning, if set, is the SecretDataValid condition reason set on the Lockbox
+		expectedWarning string
 	}
 
 	run := func(t *testing.T, tc testCase) {
@@ -35,6 +44,7 @@ func TestSecretReconciler(t *testing.T) {
 
 		client := clientfake.NewClientBuilder().
 			WithObjects(tc.resources...).
+			WithStatusSubresource(&lockboxv1.Lockbox{}).
 			WithScheme(scheme).
 			Build()
 
@@ -51,6 +61,23 @@ func TestSecretReconciler(t *testing.T) {
 			assert.NilError(t, err)
 		}
 
+		if tc.expectedReason != "" {
+			lb := &lockboxv1.Lockbox{}
+			assert.NilError(t, client.Get(context.Background(), lsn, lb))
+			cond := conditions.Get(lb, lockboxv1.ReadyCondition)
+			assert.Assert(t, cond != nil)
+			assert.Equal(t, cond.Reason, tc.expectedReason)
+		}
+
+		if tc.expectedWarning != "" {
+			lb := &lockboxv1.Lockbox{}
+			assert.NilError(t, client.Get(context.Background(), lsn, lb))
+			cond := conditions.Get(lb, lockboxv1.SecretDataValidCondition)
+			assert.Assert(t, cond != nil)
+			assert.Equal(t, cond.Reason, tc.expectedWarning)
+			assert.Equal(t, cond.Severity, lockboxv1.ConditionSeverityWarning)
+		}
+
 		actual := &corev1.Secret{}
 		err = client.Get(context.Background(), lsn, actual)
 
@@ -129,6 +156,118 @@ func TestSecretReconciler(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:        "templated lockbox",
+			lockboxName: "example",
+			resources: []client.Object{
+				&lockboxv1.Lockbox{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "example",
+						Namespace: "example",
+						Labels: map[string]string{
+							"type": "lockbox",
+						},
+						Annotations: map[string]string{
+							"helm.sh/hook": "pre-install",
+						},
+					},
+					Spec: lockboxv1.LockboxSpec{
+						Sender:    []byte{0xb2, 0xa3, 0xf, 0x85, 0xa, 0x58, 0xcf, 0x94, 0x4c, 0x62, 0x37, 0xd4, 0xef, 0xf5, 0xed, 0x11, 0x52, 0xfa, 0x1b, 0xc3, 0xb0, 0x4d, 0x27, 0xd5, 0x58, 0x67, 0x61, 0x67, 0xe0, 0x10, 0xb1, 0x5c},
+						Peer:      []byte{0x6a, 0x42, 0xb9, 0xfc, 0x2b, 0x1, 0x1f, 0xb8, 0x8c, 0x1, 0x74, 0x14, 0x83, 0xe3, 0xbf, 0xfe, 0x45, 0x5b, 0xda, 0xb1, 0xae, 0x35, 0xd0, 0xbb, 0x53, 0xa3, 0xc0, 0xd, 0x40, 0x6d, 0x88, 0x36},
+						Namespace: []byte{0x4d, 0xa0, 0x73, 0x8b, 0x95, 0xc3, 0xd4, 0x64, 0xe9, 0xab, 0xd, 0xb7, 0x1e, 0x5, 0x10, 0xed, 0x4c, 0x2f, 0x8a, 0x66, 0x6d, 0xec, 0x7c, 0x5d, 0x9b, 0xa7, 0xb7, 0x88, 0x49, 0x8a, 0xb9, 0x7f, 0xf0, 0x30, 0xe0, 0xad, 0x49, 0x7c, 0x3f, 0xe3, 0x1c, 0x2e, 0xe9, 0xb1, 0x2a, 0x70, 0x28},
+						Template: lockboxv1.LockboxSecretTemplate{
+							LockboxSecretTemplateMetadata: lockboxv1.LockboxSecretTemplateMetadata{
+								Labels: map[string]string{
+									"type": "secret",
+								},
+								Annotations: map[string]string{
+									"wave": "ignore",
+								},
+							},
+							Type: corev1.SecretTypeOpaque,
+							Data: map[string]string{
+								"config.yaml": "user: {{ .test }}\npassword: {{ .test1 }}\n",
+							},
+						},
+						Data: map[string][]byte{
+							"test":  {0x7b, 0xca, 0x32, 0x90, 0xf7, 0x97, 0x3b, 0x6, 0xfb, 0x7c, 0xdc, 0x3a, 0x25, 0x82, 0x29, 0xdf, 0x9d, 0x1e, 0x46, 0x8d, 0xd4, 0x99, 0x49, 0x2, 0x63, 0x56, 0x54, 0x64, 0xae, 0x9e, 0xf2, 0xc0, 0x35, 0xf5, 0xf1, 0xcb, 0x67, 0xb7, 0xe2, 0xb1, 0x14, 0x42, 0x71, 0xc},
+							"test1": {0x2c, 0x68, 0xed, 0x53, 0x55, 0x55, 0xe2, 0x2d, 0x71, 0x96, 0x85, 0xfd, 0xdb, 0x93, 0x1e, 0x77, 0x91, 0x2d, 0x76, 0xba, 0xae, 0x46, 0x30, 0x9e, 0xb6, 0x65, 0xa2, 0x49, 0xfe, 0x78, 0xc0, 0xcb, 0x6d, 0xf, 0xa8, 0xeb, 0xa8, 0xfc, 0xc0, 0xa0, 0xdc, 0x4, 0x16, 0x7, 0xa0},
+						},
+					},
+				},
+			},
+			expected: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "example",
+					Namespace: "example",
+					Labels: map[string]string{
+						"type": "secret",
+					},
+					Annotations: map[string]string{
+						"wave": "ignore",
+					},
+					ResourceVersion: "1",
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "lockbox.k8s.cloudflare.com/v1",
+							Kind:               "Lockbox",
+							Name:               "example",
+							Controller:         ptr.To(true),
+							BlockOwnerDeletion: ptr.To(true),
+						},
+					},
+				},
+				Type: corev1.SecretTypeOpaque,
+				Data: map[string][]byte{
+					"test":        []byte("test"),
+					"test1":       []byte("test1"),
+					"config.yaml": []byte("user: test\npassword: test1\n"),
+				},
+			},
+		},
+		{
+			name:        "template error",
+			lockboxName: "example",
+			resources: []client.Object{
+				&lockboxv1.Lockbox{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "example",
+						Namespace: "example",
+						Labels: map[string]string{
+							"type": "lockbox",
+						},
+						Annotations: map[string]string{
+							"helm.sh/hook": "pre-install",
+						},
+					},
+					Spec: lockboxv1.LockboxSpec{
+						Sender:    []byte{0xb2, 0xa3, 0xf, 0x85, 0xa, 0x58, 0xcf, 0x94, 0x4c, 0x62, 0x37, 0xd4, 0xef, 0xf5, 0xed, 0x11, 0x52, 0xfa, 0x1b, 0xc3, 0xb0, 0x4d, 0x27, 0xd5, 0x58, 0x67, 0x61, 0x67, 0xe0, 0x10, 0xb1, 0x5c},
+						Peer:      []byte{0x6a, 0x42, 0xb9, 0xfc, 0x2b, 0x1, 0x1f, 0xb8, 0x8c, 0x1, 0x74, 0x14, 0x83, 0xe3, 0xbf, 0xfe, 0x45, 0x5b, 0xda, 0xb1, 0xae, 0x35, 0xd0, 0xbb, 0x53, 0xa3, 0xc0, 0xd, 0x40, 0x6d, 0x88, 0x36},
+						Namespace: []byte{0x4d, 0xa0, 0x73, 0x8b, 0x95, 0xc3, 0xd4, 0x64, 0xe9, 0xab, 0xd, 0xb7, 0x1e, 0x5, 0x10, 0xed, 0x4c, 0x2f, 0x8a, 0x66, 0x6d, 0xec, 0x7c, 0x5d, 0x9b, 0xa7, 0xb7, 0x88, 0x49, 0x8a, 0xb9, 0x7f, 0xf0, 0x30, 0xe0, 0xad, 0x49, 0x7c, 0x3f, 0xe3, 0x1c, 0x2e, 0xe9, 0xb1, 0x2a, 0x70, 0x28},
+						Template: lockboxv1.LockboxSecretTemplate{
+							LockboxSecretTemplateMetadata: lockboxv1.LockboxSecretTemplateMetadata{
+								Labels: map[string]string{
+									"type": "secret",
+								},
+								Annotations: map[string]string{
+									"wave": "ignore",
+								},
+							},
+							Type: corev1.SecretTypeOpaque,
+							Data: map[string]string{
+								"config.yaml": "user: {{ .test }}\npassword: {{ .missing }}\n",
+							},
+						},
+						Data: map[string][]byte{
+							"test":  {0x7b, 0xca, 0x32, 0x90, 0xf7, 0x97, 0x3b, 0x6, 0xfb, 0x7c, 0xdc, 0x3a, 0x25, 0x82, 0x29, 0xdf, 0x9d, 0x1e, 0x46, 0x8d, 0xd4, 0x99, 0x49, 0x2, 0x63, 0x56, 0x54, 0x64, 0xae, 0x9e, 0xf2, 0xc0, 0x35, 0xf5, 0xf1, 0xcb, 0x67, 0xb7, 0xe2, 0xb1, 0x14, 0x42, 0x71, 0xc},
+							"test1": {0x2c, 0x68, 0xed, 0x53, 0x55, 0x55, 0xe2, 0x2d, 0x71, 0x96, 0x85, 0xfd, 0xdb, 0x93, 0x1e, 0x77, 0x91, 0x2d, 0x76, 0xba, 0xae, 0x46, 0x30, 0x9e, 0xb6, 0x65, 0xa2, 0x49, 0xfe, 0x78, 0xc0, 0xcb, 0x6d, 0xf, 0xa8, 0xeb, 0xa8, 0xfc, 0xc0, 0xa0, 0xdc, 0x4, 0x16, 0x7, 0xa0},
+						},
+					},
+				},
+			},
+			expectedErr:    `map has no entry for key "missing"`,
+			expectedReason: "TemplateError",
+		},
 		{
 			name:        "update lockbox secret",
 			lockboxName: "example",
@@ -265,6 +404,49 @@ func TestSecretReconciler(t *testing.T) {
 		{
 			name:        "docker-registry secret",
 			lockboxName: "example",
+			resources: []client.Object{
+				&lockboxv1.Lockbox{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "example",
+						Namespace: "example",
+					},
+					Spec: lockboxv1.LockboxSpec{
+						Sender:    []byte{0x58, 0xd6, 0xbb, 0x52, 0x69, 0xb5, 0xda, 0xb4, 0x56, 0x4b, 0x90, 0xec, 0x1a, 0xe4, 0x35, 0x51, 0xc9, 0xc, 0xdf, 0x16, 0x96, 0xea, 0x14, 0x2f, 0x63, 0x8d, 0xf9, 0xc2, 0x2b, 0x14, 0x2d, 0x36},
+						Peer:      []byte{0x6a, 0x42, 0xb9, 0xfc, 0x2b, 0x1, 0x1f, 0xb8, 0x8c, 0x1, 0x74, 0x14, 0x83, 0xe3, 0xbf, 0xfe, 0x45, 0x5b, 0xda, 0xb1, 0xae, 0x35, 0xd0, 0xbb, 0x53, 0xa3, 0xc0, 0xd, 0x40, 0x6d, 0x88, 0x36},
+						Namespace: []byte{0x28, 0xcc, 0xbd, 0xa, 0xb8, 0xbf, 0x86, 0xa6, 0x3f, 0x37, 0xe8, 0xcf, 0xe9, 0x24, 0x6d, 0x4a, 0xe, 0x60, 0xc4, 0xac, 0x9b, 0xa2, 0x6c, 0xf0, 0xb2, 0x9b, 0xf1, 0xd0, 0x76, 0x89, 0xe6, 0x5d, 0x70, 0x71, 0x63, 0x7b, 0x16, 0xfd, 0xc3, 0x3b, 0xfb, 0xc8, 0x94, 0x3c, 0xaa, 0x67, 0x95},
+						Data: map[string][]byte{
+							".dockerconfigjson": {0x7b, 0x19, 0x5d, 0xfb, 0x6f, 0x44, 0xf, 0x41, 0xcd, 0x32, 0x2, 0x31, 0x9a, 0x8c, 0xc, 0x11, 0x2f, 0xb7, 0xad, 0xe0, 0x9d, 0x1e, 0xec, 0x1f, 0x7e, 0x68, 0x4, 0x69, 0xa1, 0xd0, 0x66, 0x6f, 0x9a, 0xeb, 0x7f, 0x7d, 0x57, 0x98, 0x41, 0x67, 0xc9, 0x83, 0x8, 0x94, 0xb7, 0x7b, 0xf2, 0x6b, 0x59, 0x97, 0x68, 0x2e, 0x8a, 0xd3, 0x16, 0xc2, 0x28, 0x63, 0x15, 0x7c, 0xfc, 0x2f, 0x7, 0x41, 0x91, 0x4f, 0xcb, 0x68, 0xeb, 0x6, 0xa6, 0x89, 0x58, 0x33, 0xb3, 0x42, 0x3c, 0x1d, 0x63, 0x68, 0x6e, 0xb6, 0x9, 0xb3, 0xe1, 0x9d, 0x1e, 0x47, 0x33, 0xc9, 0xf6, 0xfc, 0xcd, 0x6a, 0xcc, 0x69, 0x2b, 0x31, 0xec, 0x93, 0x36, 0xbe, 0xaa, 0x1a, 0x3c, 0x61, 0x1b, 0x71, 0xe3, 0x9d, 0xc7, 0x2d, 0x30, 0x74, 0x99, 0x89, 0x3f, 0xde, 0x3a, 0xf3, 0xad, 0xcc, 0x50, 0xa6, 0x18, 0x24, 0x9d, 0x44, 0x31, 0x48, 0x5d, 0x43, 0xde, 0x33, 0x94, 0xce, 0x4c, 0x12, 0xdf, 0x69, 0xec, 0x75, 0xb2, 0x49, 0xcb, 0xa1, 0xa4, 0xbc},
+						},
+						Template: lockboxv1.LockboxSecretTemplate{
+							Type: corev1.SecretTypeDockerConfigJson,
+						},
+					},
+				},
+			},
+			expected: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "example",
+					Namespace:       "example",
+					ResourceVersion: "1",
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "lockbox.k8s.cloudflare.com/v1",
+							Kind:               "Lockbox",
+							Name:               "example",
+							Controller:         ptr.To(true),
+							BlockOwnerDeletion: ptr.To(true),
+						},
+					},
+				},
+				Type: corev1.SecretTypeDockerConfigJson,
+				Data: map[string][]byte{
+					".dockerconfigjson": []byte(`{"auths":{"docker.example.com":{"Username":"joedeveloper","Password":"password","Email":"joe@example.com"}}}`),
+				},
+			},
+		},
+		{
+			name:        "docker-registry secret with encoded config",
+			lockboxName: "example",
 			resources: []client.Object{
 				&lockboxv1.Lockbox{
 					ObjectMeta: metav1.ObjectMeta{
@@ -301,9 +483,10 @@ func TestSecretReconciler(t *testing.T) {
 				},
 				Type: corev1.SecretTypeDockerConfigJson,
 				Data: map[string][]byte{
-					".dockerconfigjson": {0x65, 0x79, 0x4a, 0x68, 0x64, 0x58, 0x52, 0x6f, 0x63, 0x79, 0x49, 0x36, 0x65, 0x79, 0x4a, 0x6b, 0x62, 0x32, 0x4e, 0x72, 0x5a, 0x58, 0x49, 0x75, 0x5a, 0x58, 0x68, 0x68, 0x62, 0x58, 0x42, 0x73, 0x5a, 0x53, 0x35, 0x6a, 0x62, 0x32, 0x30, 0x69, 0x4f, 0x6e, 0x73, 0x69, 0x56, 0x58, 0x4e, 0x6c, 0x63, 0x6d, 0x35, 0x68, 0x62, 0x57, 0x55, 0x69, 0x4f, 0x69, 0x4a, 0x71, 0x62, 0x32, 0x56, 0x6b, 0x5a, 0x58, 0x5a, 0x6c, 0x62, 0x47, 0x39, 0x77, 0x5a, 0x58, 0x49, 0x69, 0x4c, 0x43, 0x4a, 0x51, 0x59, 0x58, 0x4e, 0x7a, 0x64, 0x32, 0x39, 0x79, 0x5a, 0x43, 0x49, 0x36, 0x49, 0x6e, 0x42, 0x68, 0x63, 0x33, 0x4e, 0x33, 0x62, 0x33, 0x4a, 0x6b, 0x49, 0x69, 0x77, 0x69, 0x52, 0x57, 0x31, 0x68, 0x61, 0x57, 0x77, 0x69, 0x4f, 0x69, 0x4a, 0x71, 0x62, 0x32, 0x56, 0x41, 0x5a, 0x58, 0x68, 0x68, 0x62, 0x58, 0x42, 0x73, 0x5a, 0x53, 0x35, 0x6a, 0x62, 0x32, 0x30, 0x69, 0x66, 0x58, 0x31, 0x39},
+					".dockerconfigjson": []byte("eyJhdXRocyI6eyJkb2NrZXIuZXhhbXBsZS5jb20iOnsiVXNlcm5hbWUiOiJqb2VkZXZlbG9wZXIiLCJQYXNzd29yZCI6InBhc3N3b3JkIiwiRW1haWwiOiJqb2VAZXhhbXBsZS5jb20ifX19"),
 				},
 			},
+			expectedWarning: "InvalidSecretData",
 		},
 	}
 
@@ -315,6 +498,574 @@ func TestSecretReconciler(t *testing.T) {
 	}
 }
 
+func TestSecretReconcilerProjections(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NilError(t, corev1.AddToScheme(scheme))
+	assert.NilError(t, lockboxv1.AddToScheme(scheme))
+
+	lb := exampleLockbox(lockboxv1.LockboxSecretTemplate{
+		Projections: []lockboxv1.LockboxSecretProjection{
+			{
+				Name: "example-auth",
+				LockboxSecretTemplateMetadata: lockboxv1.LockboxSecretTemplateMetadata{
+					Labels: map[string]string{
+						"type": "auth",
+					},
+				},
+				Type: corev1.SecretTypeBasicAuth,
+				Keys: []lockboxv1.LockboxKeyProjection{
+					{Key: "test", Name: corev1.BasicAuthUsernameKey},
+					{Key: "test1", Name: corev1.BasicAuthPasswordKey},
+				},
+			},
+			{
+				Name: "example-opaque",
+				Type: corev1.SecretTypeOpaque,
+				Keys: []lockboxv1.LockboxKeyProjection{
+					{Key: "test1"},
+				},
+			},
+		},
+	})
+	// previously created before projections were added, and should be pruned
+	stale := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example",
+			Namespace: "example",
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         "lockbox.k8s.cloudflare.com/v1",
+					Kind:               "Lockbox",
+					Name:               "example",
+					UID:                "lockbox-uid",
+					Controller:         ptr.To(true),
+					BlockOwnerDeletion: ptr.To(true),
+				},
+			},
+		},
+	}
+
+	client := clientfake.NewClientBuilder().
+		WithObjects(lb, stale).
+		WithStatusSubresource(&lockboxv1.Lockbox{}).
+		WithScheme(scheme).
+		Build()
+
+	pubKey, priKey, err := loadKeypair(t, "6a42b9fc2b011fb88c01741483e3bffe455bdab1ae35d0bb53a3c00d406d8836", "252173f975f0a0ddb198a7e5958c074203a0e9f44275e0b840f95d456c4acc2e")
+	assert.NilError(t, err)
+
+	sr := controller.NewSecretReconciler(pubKey, priKey, controller.WithClient(client))
+	_, err = reconcile.AsReconciler(client, sr).Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: "example", Namespace: "example"}})
+	assert.NilError(t, err)
+
+	auth := &corev1.Secret{}
+	assert.NilError(t, client.Get(context.Background(), types.NamespacedName{Name: "example-auth", Namespace: "example"}, auth))
+	assert.Equal(t, auth.Type, corev1.SecretTypeBasicAuth)
+	assert.DeepEqual(t, auth.Labels, map[string]string{"type": "auth"})
+	assert.DeepEqual(t, auth.Data, map[string][]byte{
+		corev1.BasicAuthUsernameKey: []byte("test"),
+		corev1.BasicAuthPasswordKey: []byte("test1"),
+	})
+
+	opaque := &corev1.Secret{}
+	assert.NilError(t, client.Get(context.Background(), types.NamespacedName{Name: "example-opaque", Namespace: "example"}, opaque))
+	assert.Equal(t, opaque.Type, corev1.SecretTypeOpaque)
+	assert.DeepEqual(t, opaque.Data, map[string][]byte{
+		"test1": []byte("test1"),
+	})
+
+	err = client.Get(context.Background(), types.NamespacedName{Name: "example", Namespace: "example"}, &corev1.Secret{})
+	assert.Assert(t, apierrors.IsNotFound(err))
+}
+
+func TestSecretReconcilerDuplicateProjections(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NilError(t, corev1.AddToScheme(scheme))
+	assert.NilError(t, lockboxv1.AddToScheme(scheme))
+
+	lb := exampleLockbox(lockboxv1.LockboxSecretTemplate{
+		Projections: []lockboxv1.LockboxSecretProjection{
+			{Name: "example-opaque", Keys: []lockboxv1.LockboxKeyProjection{{Key: "test"}}},
+			{Name: "example-opaque", Keys: []lockboxv1.LockboxKeyProjection{{Key: "test1"}}},
+		},
+	})
+
+	client := clientfake.NewClientBuilder().
+		WithObjects(lb).
+		WithStatusSubresource(&lockboxv1.Lockbox{}).
+		WithScheme(scheme).
+		Build()
+
+	pubKey, priKey, err := loadKeypair(t, "6a42b9fc2b011fb88c01741483e3bffe455bdab1ae35d0bb53a3c00d406d8836", "252173f975f0a0ddb198a7e5958c074203a0e9f44275e0b840f95d456c4acc2e")
+	assert.NilError(t, err)
+
+	lsn := types.NamespacedName{Name: "example", Namespace: "example"}
+	sr := controller.NewSecretReconciler(pubKey, priKey, controller.WithClient(client))
+	_, err = reconcile.AsReconciler(client, sr).Reconcile(context.Background(), reconcile.Request{NamespacedName: lsn})
+	assert.ErrorContains(t, err, `duplicate projection name "example-opaque"`)
+
+	assert.NilError(t, client.Get(context.Background(), lsn, lb))
+	cond := conditions.Get(lb, lockboxv1.ReadyCondition)
+	assert.Assert(t, cond != nil)
+	assert.Equal(t, cond.Reason, "InvalidLockbox")
+
+	err = client.Get(context.Background(), types.NamespacedName{Name: "example-opaque", Namespace: "example"}, &corev1.Secret{})
+	assert.Assert(t, apierrors.IsNotFound(err))
+}
+
+func TestSecretReconcilerMissingSecretKeys(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NilError(t, corev1.AddToScheme(scheme))
+	assert.NilError(t, lockboxv1.AddToScheme(scheme))
+
+	lb := exampleLockbox(lockboxv1.LockboxSecretTemplate{
+		Projections: []lockboxv1.LockboxSecretProjection{
+			{Name: "example-opaque", Keys: []lockboxv1.LockboxKeyProjection{{Key: "test"}}},
+			{Name: "example-tls", Type: corev1.SecretTypeTLS, Keys: []lockboxv1.LockboxKeyProjection{{Key: "test1", Name: corev1.TLSCertKey}}},
+		},
+	})
+
+	client := clientfake.NewClientBuilder().
+		WithObjects(lb).
+		WithStatusSubresource(&lockboxv1.Lockbox{}).
+		WithScheme(scheme).
+		Build()
+
+	pubKey, priKey, err := loadKeypair(t, "6a42b9fc2b011fb88c01741483e3bffe455bdab1ae35d0bb53a3c00d406d8836", "252173f975f0a0ddb198a7e5958c074203a0e9f44275e0b840f95d456c4acc2e")
+	assert.NilError(t, err)
+
+	lsn := types.NamespacedName{Name: "example", Namespace: "example"}
+	sr := controller.NewSecretReconciler(pubKey, priKey, controller.WithClient(client))
+	_, err = reconcile.AsReconciler(client, sr).Reconcile(context.Background(), reconcile.Request{NamespacedName: lsn})
+	assert.ErrorContains(t, err, "kubernetes.io/tls Secret is missing keys: tls.key")
+
+	assert.NilError(t, client.Get(context.Background(), lsn, lb))
+	cond := conditions.Get(lb, lockboxv1.ReadyCondition)
+	assert.Assert(t, cond != nil)
+	assert.Equal(t, cond.Reason, "InvalidSecretData")
+
+	err = client.Get(context.Background(), types.NamespacedName{Name: "example-opaque", Namespace: "example"}, &corev1.Secret{})
+	assert.Assert(t, apierrors.IsNotFound(err))
+}
+
+func TestSecretReconcilerMissingSecretAnnotations(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NilError(t, corev1.AddToScheme(scheme))
+	assert.NilError(t, lockboxv1.AddToScheme(scheme))
+
+	lb := exampleLockbox(lockboxv1.LockboxSecretTemplate{
+		Projections: []lockboxv1.LockboxSecretProjection{
+			{Name: "example-opaque", Keys: []lockboxv1.LockboxKeyProjection{{Key: "test"}}},
+			{Name: "example-token", Type: corev1.SecretTypeServiceAccountToken, Keys: []lockboxv1.LockboxKeyProjection{{Key: "test1", Name: corev1.ServiceAccountTokenKey}}},
+		},
+	})
+
+	client := clientfake.NewClientBuilder().
+		WithObjects(lb).
+		WithStatusSubresource(&lockboxv1.Lockbox{}).
+		WithScheme(scheme).
+		Build()
+
+	pubKey, priKey, err := loadKeypair(t, "6a42b9fc2b011fb88c01741483e3bffe455bdab1ae35d0bb53a3c00d406d8836", "252173f975f0a0ddb198a7e5958c074203a0e9f44275e0b840f95d456c4acc2e")
+	assert.NilError(t, err)
+
+	lsn := types.NamespacedName{Name: "example", Namespace: "example"}
+	sr := controller.NewSecretReconciler(pubKey, priKey, controller.WithClient(client))
+	_, err = reconcile.AsReconciler(client, sr).Reconcile(context.Background(), reconcile.Request{NamespacedName: lsn})
+	assert.ErrorContains(t, err, "kubernetes.io/service-account-token Secret is missing annotations: kubernetes.io/service-account.name")
+
+	assert.NilError(t, client.Get(context.Background(), lsn, lb))
+	cond := conditions.Get(lb, lockboxv1.ReadyCondition)
+	assert.Assert(t, cond != nil)
+	assert.Equal(t, cond.Reason, "InvalidSecretData")
+
+	for _, name := range []string{"example-opaque", "example-token"} {
+		err = client.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "example"}, &corev1.Secret{})
+		assert.Assert(t, apierrors.IsNotFound(err))
+	}
+}
+
+func TestSecretReconcilerImmutable(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NilError(t, corev1.AddToScheme(scheme))
+	assert.NilError(t, lockboxv1.AddToScheme(scheme))
+
+	pubKey, priKey, err := loadKeypair(t, "6a42b9fc2b011fb88c01741483e3bffe455bdab1ae35d0bb53a3c00d406d8836", "252173f975f0a0ddb198a7e5958c074203a0e9f44275e0b840f95d456c4acc2e")
+	assert.NilError(t, err)
+
+	ownedSecret := func(name string, labels, annotations map[string]string, data map[string][]byte) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "example",
+				Labels:      labels,
+				Annotations: annotations,
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion:         "lockbox.k8s.cloudflare.com/v1",
+						Kind:               "Lockbox",
+						Name:               "example",
+						UID:                "lockbox-uid",
+						Controller:         ptr.To(true),
+						BlockOwnerDeletion: ptr.To(true),
+					},
+				},
+			},
+			Immutable: ptr.To(true),
+			Data:      data,
+		}
+	}
+
+	t.Run("hash", func(t *testing.T) {
+		lb := exampleLockbox(lockboxv1.LockboxSecretTemplate{
+			Type: corev1.SecretTypeOpaque,
+			Immutable: &lockboxv1.LockboxSecretImmutability{
+				Strategy:        lockboxv1.ImmutableStrategyHash,
+				RetentionPeriod: &metav1.Duration{Duration: time.Hour},
+			},
+		})
+		projectionLabel := map[string]string{lockboxv1.ProjectionLabel: "example"}
+		expired := ownedSecret("example-expired", projectionLabel, map[string]string{
+			lockboxv1.SupersededAnnotation: time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339),
+		}, nil)
+		retained := ownedSecret("example-retained", projectionLabel, nil, nil)
+
+		client := clientfake.NewClientBuilder().
+			WithObjects(lb, expired, retained).
+			WithStatusSubresource(&lockboxv1.Lockbox{}).
+			WithScheme(scheme).
+			Build()
+
+		sr := controller.NewSecretReconciler(pubKey, priKey, controller.WithClient(client))
+		lsn := types.NamespacedName{Name: "example", Namespace: "example"}
+		res, err := reconcile.AsReconciler(client, sr).Reconcile(context.Background(), reconcile.Request{NamespacedName: lsn})
+		assert.NilError(t, err)
+		assert.Assert(t, res.RequeueAfter > 59*time.Minute && res.RequeueAfter <= time.Hour)
+
+		projections, err := lb.SecretProjections()
+		assert.NilError(t, err)
+		name := lb.SecretName(&projections[0], map[string][]byte{
+			"test":  []byte("test"),
+			"test1": []byte("test1"),
+		})
+		assert.Assert(t, name != "example")
+
+		actual := &corev1.Secret{}
+		assert.NilError(t, client.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "example"}, actual))
+		assert.Equal(t, *actual.Immutable, true)
+		assert.DeepEqual(t, actual.Labels, projectionLabel)
+		assert.DeepEqual(t, actual.Data, map[string][]byte{
+			"test":  []byte("test"),
+			"test1": []byte("test1"),
+		})
+
+		err = client.Get(context.Background(), types.NamespacedName{Name: "example-expired", Namespace: "example"}, &corev1.Secret{})
+		assert.Assert(t, apierrors.IsNotFound(err))
+
+		actual = &corev1.Secret{}
+		assert.NilError(t, client.Get(context.Background(), types.NamespacedName{Name: "example-retained", Namespace: "example"}, actual))
+		assert.Assert(t, actual.Annotations[lockboxv1.SupersededAnnotation] != "")
+
+		updated := &lockboxv1.Lockbox{}
+		assert.NilError(t, client.Get(context.Background(), lsn, updated))
+		assert.DeepEqual(t, updated.Status.Secrets, []string{name})
+	})
+
+	t.Run("hash resealed", func(t *testing.T) {
+		seal := func() *lockboxv1.Lockbox {
+			senderPub, senderPri, err := box.GenerateKey(rand.Reader)
+			assert.NilError(t, err)
+
+			secret := corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "example"},
+				Data:       map[string][]byte{"test": []byte("value")},
+			}
+			lb := lockboxv1.NewFromSecret(secret, "example", pubKey, senderPub, senderPri)
+			lb.Spec.Template.Immutable = &lockboxv1.LockboxSecretImmutability{Strategy: lockboxv1.ImmutableStrategyHash}
+			return lb
+		}
+
+		lb := seal()
+		client := clientfake.NewClientBuilder().
+			WithObjects(lb).
+			WithStatusSubresource(&lockboxv1.Lockbox{}).
+			WithScheme(scheme).
+			Build()
+
+		sr := controller.NewSecretReconciler(pubKey, priKey, controller.WithClient(client))
+		lsn := types.NamespacedName{Name: "example", Namespace: "example"}
+		_, err := reconcile.AsReconciler(client, sr).Reconcile(context.Background(), reconcile.Request{NamespacedName: lsn})
+		assert.NilError(t, err)
+
+		first := &lockboxv1.Lockbox{}
+		assert.NilError(t, client.Get(context.Background(), lsn, first))
+		assert.Equal(t, len(first.Status.Secrets), 1)
+
+		// Sealing the same data again produces different ciphertext.
+		resealed := seal()
+		assert.Assert(t, string(resealed.Spec.Data["test"]) != string(first.Spec.Data["test"]))
+		first.Spec = resealed.Spec
+		assert.NilError(t, client.Update(context.Background(), first))
+
+		_, err = reconcile.AsReconciler(client, sr).Reconcile(context.Background(), reconcile.Request{NamespacedName: lsn})
+		assert.NilError(t, err)
+
+		second := &lockboxv1.Lockbox{}
+		assert.NilError(t, client.Get(context.Background(), lsn, second))
+		assert.DeepEqual(t, second.Status.Secrets, first.Status.Secrets)
+
+		secrets := &corev1.SecretList{}
+		assert.NilError(t, client.List(context.Background(), secrets))
+		assert.Equal(t, len(secrets.Items), 1)
+	})
+
+	t.Run("recreate", func(t *testing.T) {
+		lb := exampleLockbox(lockboxv1.LockboxSecretTemplate{
+			Type: corev1.SecretTypeOpaque,
+			Immutable: &lockboxv1.LockboxSecretImmutability{
+				Strategy: lockboxv1.ImmutableStrategyRecreate,
+			},
+		})
+		existing := ownedSecret("example", nil, nil, map[string][]byte{
+			"test": []byte("outdated"),
+		})
+		existing.UID = "outdated-uid"
+
+		client := clientfake.NewClientBuilder().
+			WithObjects(lb, existing).
+			WithStatusSubresource(&lockboxv1.Lockbox{}).
+			WithScheme(scheme).
+			Build()
+
+		sr := controller.NewSecretReconciler(pubKey, priKey, controller.WithClient(client))
+		lsn := types.NamespacedName{Name: "example", Namespace: "example"}
+		_, err := reconcile.AsReconciler(client, sr).Reconcile(context.Background(), reconcile.Request{NamespacedName: lsn})
+		assert.NilError(t, err)
+
+		actual := &corev1.Secret{}
+		assert.NilError(t, client.Get(context.Background(), lsn, actual))
+		assert.Assert(t, actual.UID != "outdated-uid")
+		assert.Equal(t, *actual.Immutable, true)
+		assert.DeepEqual(t, actual.Data, map[string][]byte{
+			"test":  []byte("test"),
+			"test1": []byte("test1"),
+		})
+	})
+}
+
+func TestSecretReconcilerNamespaceKey(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NilError(t, corev1.AddToScheme(scheme))
+	assert.NilError(t, lockboxv1.AddToScheme(scheme))
+
+	pubKey, priKey, err := loadKeypair(t, "6a42b9fc2b011fb88c01741483e3bffe455bdab1ae35d0bb53a3c00d406d8836", "252173f975f0a0ddb198a7e5958c074203a0e9f44275e0b840f95d456c4acc2e")
+	assert.NilError(t, err)
+
+	run := func(t *testing.T, sealedFor, namespace, expectedReason string) {
+		nsPub, _, err := keyring.DeriveNamespaceKey(keyring.StaticKey(priKey), sealedFor)
+		assert.NilError(t, err)
+		senderPub, senderPri, err := box.GenerateKey(rand.Reader)
+		assert.NilError(t, err)
+
+		secret := corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "example"},
+			Data:       map[string][]byte{"test": []byte("value")},
+		}
+		lb := lockboxv1.NewFromSecret(secret, namespace, nsPub, senderPub, senderPri)
+
+		client := clientfake.NewClientBuilder().
+			WithObjects(lb).
+			WithStatusSubresource(&lockboxv1.Lockbox{}).
+			WithScheme(scheme).
+			Build()
+
+		sr := controller.NewSecretReconciler(pubKey, priKey, controller.WithClient(client))
+		lsn := types.NamespacedName{Name: "example", Namespace: namespace}
+		_, err = reconcile.AsReconciler(client, sr).Reconcile(context.Background(), reconcile.Request{NamespacedName: lsn})
+		if expectedReason == "" {
+			assert.NilError(t, err)
+			return
+		}
+		assert.Assert(t, err != nil)
+
+		assert.NilError(t, client.Get(context.Background(), lsn, lb))
+		assert.Equal(t, conditions.Get(lb, lockboxv1.ReadyCondition).Reason, expectedReason)
+	}
+
+	t.Run("matching namespace", func(t *testing.T) {
+		run(t, "example", "example", "")
+	})
+
+	t.Run("other namespace", func(t *testing.T) {
+		run(t, "other", "example", controller.UnknownPeerKeyReason)
+	})
+}
+
+func TestSecretReconcilerRecipients(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NilError(t, corev1.AddToScheme(scheme))
+	assert.NilError(t, lockboxv1.AddToScheme(scheme))
+
+	pubKey, priKey, err := loadKeypair(t, "6a42b9fc2b011fb88c01741483e3bffe455bdab1ae35d0bb53a3c00d406d8836", "252173f975f0a0ddb198a7e5958c074203a0e9f44275e0b840f95d456c4acc2e")
+	assert.NilError(t, err)
+	escrowPub, _, err := box.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+	senderPub, senderPri, err := box.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "example"},
+		Data:       map[string][]byte{"test": []byte("value")},
+	}
+	// The controller only holds the key of the second recipient.
+	lb := lockboxv1.NewFromSecretWithRecipients(secret, "example", []nacl.Key{escrowPub, pubKey}, senderPub, senderPri)
+
+	client := clientfake.NewClientBuilder().
+		WithObjects(lb).
+		WithStatusSubresource(&lockboxv1.Lockbox{}).
+		WithScheme(scheme).
+		Build()
+
+	sr := controller.NewSecretReconciler(pubKey, priKey, controller.WithClient(client))
+	lsn := types.NamespacedName{Name: "example", Namespace: "example"}
+	_, err = reconcile.AsReconciler(client, sr).Reconcile(context.Background(), reconcile.Request{NamespacedName: lsn})
+	assert.NilError(t, err)
+
+	actual := &corev1.Secret{}
+	assert.NilError(t, client.Get(context.Background(), lsn, actual))
+	assert.DeepEqual(t, actual.Data, map[string][]byte{"test": []byte("value")})
+}
+
+func TestSecretReconcilerHybrid(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NilError(t, corev1.AddToScheme(scheme))
+	assert.NilError(t, lockboxv1.AddToScheme(scheme))
+
+	pubKey, priKey, err := loadKeypair(t, "6a42b9fc2b011fb88c01741483e3bffe455bdab1ae35d0bb53a3c00d406d8836", "252173f975f0a0ddb198a7e5958c074203a0e9f44275e0b840f95d456c4acc2e")
+	assert.NilError(t, err)
+	senderPub, senderPri, err := box.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+
+	mlkemSeed, err := keyring.GenerateMLKEMSeed()
+	assert.NilError(t, err)
+	keys := keyring.New(keyring.KeyPair{Public: pubKey, Private: keyring.StaticHybridKey(priKey, mlkemSeed)})
+
+	hybridPub, err := keys.HybridPublicKey("example")
+	assert.NilError(t, err)
+	peerKey, peerKEM, err := lockboxv1.ParseHybridPublicKey(hybridPub)
+	assert.NilError(t, err)
+
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "example"},
+		Data:       map[string][]byte{"test": []byte("value")},
+	}
+	lb := lockboxv1.NewHybridFromSecret(secret, "example", peerKey, peerKEM, senderPub, senderPri)
+
+	client := clientfake.NewClientBuilder().
+		WithObjects(lb).
+		WithStatusSubresource(&lockboxv1.Lockbox{}).
+		WithScheme(scheme).
+		Build()
+
+	// Without the keypair's ML-KEM-768 seed, the Lockbox can't be unlocked.
+	sr := controller.NewSecretReconciler(pubKey, priKey, controller.WithClient(client))
+	lsn := types.NamespacedName{Name: "example", Namespace: "example"}
+	_, err = reconcile.AsReconciler(client, sr).Reconcile(context.Background(), reconcile.Request{NamespacedName: lsn})
+	assert.ErrorContains(t, err, "no ML-KEM-768 decapsulation key")
+	assert.Assert(t, apierrors.IsNotFound(client.Get(context.Background(), lsn, &corev1.Secret{})))
+
+	sr = controller.NewSecretReconciler(nil, nil, controller.WithClient(client), controller.WithKeyring(keys))
+	_, err = reconcile.AsReconciler(client, sr).Reconcile(context.Background(), reconcile.Request{NamespacedName: lsn})
+	assert.NilError(t, err)
+
+	actual := &corev1.Secret{}
+	assert.NilError(t, client.Get(context.Background(), lsn, actual))
+	assert.DeepEqual(t, actual.Data, map[string][]byte{"test": []byte("value")})
+}
+
+func TestSecretReconcilerAge(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NilError(t, corev1.AddToScheme(scheme))
+	assert.NilError(t, lockboxv1.AddToScheme(scheme))
+
+	pubKey, priKey, err := loadKeypair(t, "6a42b9fc2b011fb88c01741483e3bffe455bdab1ae35d0bb53a3c00d406d8836", "252173f975f0a0ddb198a7e5958c074203a0e9f44275e0b840f95d456c4acc2e")
+	assert.NilError(t, err)
+	nsPub, _, err := keyring.DeriveNamespaceKey(keyring.StaticKey(priKey), "example")
+	assert.NilError(t, err)
+
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "example"},
+		Data:       map[string][]byte{"test": []byte("value")},
+	}
+	lb, err := lockboxv1.NewAgeFromSecret(secret, "example", nsPub)
+	assert.NilError(t, err)
+
+	client := clientfake.NewClientBuilder().
+		WithObjects(lb).
+		WithStatusSubresource(&lockboxv1.Lockbox{}).
+		WithScheme(scheme).
+		Build()
+
+	sr := controller.NewSecretReconciler(pubKey, priKey, controller.WithClient(client))
+	lsn := types.NamespacedName{Name: "example", Namespace: "example"}
+	_, err = reconcile.AsReconciler(client, sr).Reconcile(context.Background(), reconcile.Request{NamespacedName: lsn})
+	assert.NilError(t, err)
+
+	actual := &corev1.Secret{}
+	assert.NilError(t, client.Get(context.Background(), lsn, actual))
+	assert.DeepEqual(t, actual.Data, map[string][]byte{"test": []byte("value")})
+}
+
+func TestSecretReconcilerUnsupportedAlgorithm(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NilError(t, corev1.AddToScheme(scheme))
+	assert.NilError(t, lockboxv1.AddToScheme(scheme))
+
+	pubKey, priKey, err := loadKeypair(t, "6a42b9fc2b011fb88c01741483e3bffe455bdab1ae35d0bb53a3c00d406d8836", "252173f975f0a0ddb198a7e5958c074203a0e9f44275e0b840f95d456c4acc2e")
+	assert.NilError(t, err)
+
+	lb := exampleLockbox(lockboxv1.LockboxSecretTemplate{})
+	lb.Spec.Algorithm = "rot13"
+
+	client := clientfake.NewClientBuilder().
+		WithObjects(lb).
+		WithStatusSubresource(&lockboxv1.Lockbox{}).
+		WithScheme(scheme).
+		Build()
+
+	sr := controller.NewSecretReconciler(pubKey, priKey, controller.WithClient(client))
+	lsn := types.NamespacedName{Name: "example", Namespace: "example"}
+	_, err = reconcile.AsReconciler(client, sr).Reconcile(context.Background(), reconcile.Request{NamespacedName: lsn})
+	assert.ErrorContains(t, err, "rot13")
+
+	assert.NilError(t, client.Get(context.Background(), lsn, lb))
+	ready := conditions.Get(lb, lockboxv1.ReadyCondition)
+	assert.Equal(t, ready.Reason, "UnsupportedAlgorithm")
+	assert.Equal(t, ready.Message, `lockbox uses unsupported algorithm "rot13"`)
+}
+
+// exampleLockbox returns a Lockbox in the "example" namespace sealed to the test keypair,
+// containing the keys "test" and "test1".
+func exampleLockbox(template lockboxv1.LockboxSecretTemplate) *lockboxv1.Lockbox {
+	return &lockboxv1.Lockbox{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example",
+			Namespace: "example",
+			UID:       "lockbox-uid",
+		},
+		Spec: lockboxv1.LockboxSpec{
+			Sender:    []byte{0xb2, 0xa3, 0xf, 0x85, 0xa, 0x58, 0xcf, 0x94, 0x4c, 0x62, 0x37, 0xd4, 0xef, 0xf5, 0xed, 0x11, 0x52, 0xfa, 0x1b, 0xc3, 0xb0, 0x4d, 0x27, 0xd5, 0x58, 0x67, 0x61, 0x67, 0xe0, 0x10, 0xb1, 0x5c},
+			Peer:      []byte{0x6a, 0x42, 0xb9, 0xfc, 0x2b, 0x1, 0x1f, 0xb8, 0x8c, 0x1, 0x74, 0x14, 0x83, 0xe3, 0xbf, 0xfe, 0x45, 0x5b, 0xda, 0xb1, 0xae, 0x35, 0xd0, 0xbb, 0x53, 0xa3, 0xc0, 0xd, 0x40, 0x6d, 0x88, 0x36},
+			Namespace: []byte{0x4d, 0xa0, 0x73, 0x8b, 0x95, 0xc3, 0xd4, 0x64, 0xe9, 0xab, 0xd, 0xb7, 0x1e, 0x5, 0x10, 0xed, 0x4c, 0x2f, 0x8a, 0x66, 0x6d, 0xec, 0x7c, 0x5d, 0x9b, 0xa7, 0xb7, 0x88, 0x49, 0x8a, 0xb9, 0x7f, 0xf0, 0x30, 0xe0, 0xad, 0x49, 0x7c, 0x3f, 0xe3, 0x1c, 0x2e, 0xe9, 0xb1, 0x2a, 0x70, 0x28},
+			Template:  template,
+			Data: map[string][]byte{
+				"test":  {0x7b, 0xca, 0x32, 0x90, 0xf7, 0x97, 0x3b, 0x6, 0xfb, 0x7c, 0xdc, 0x3a, 0x25, 0x82, 0x29, 0xdf, 0x9d, 0x1e, 0x46, 0x8d, 0xd4, 0x99, 0x49, 0x2, 0x63, 0x56, 0x54, 0x64, 0xae, 0x9e, 0xf2, 0xc0, 0x35, 0xf5, 0xf1, 0xcb, 0x67, 0xb7, 0xe2, 0xb1, 0x14, 0x42, 0x71, 0xc},
+				"test1": {0x2c, 0x68, 0xed, 0x53, 0x55, 0x55, 0xe2, 0x2d, 0x71, 0x96, 0x85, 0xfd, 0xdb, 0x93, 0x1e, 0x77, 0x91, 0x2d, 0x76, 0xba, 0xae, 0x46, 0x30, 0x9e, 0xb6, 0x65, 0xa2, 0x49, 0xfe, 0x78, 0xc0, 0xcb, 0x6d, 0xf, 0xa8, 0xeb, 0xa8, 0xfc, 0xc0, 0xa0, 0xdc, 0x4, 0x16, 0x7, 0xa0},
+			},
+		},
+	}
+}
+
 func loadKeypair(t *testing.T, pub, pri string) (pubKey, priKey nacl.Key, err error) {
 	t.Helper()
 
@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Checks reported by Verify.
+const (
+	// CheckPeerKey checks the controller holds the key for one of the Lockbox's recipients.
+	CheckPeerKey = "PeerKey"
+	// CheckNamespace checks the Lockbox was locked for its namespace.
+	CheckNamespace = "Namespace"
+	// CheckDataKey checks a single data value can be unlocked.
+	CheckDataKey = "DataKey"
+	// CheckTemplate checks the Lockbox's data templates can be parsed.
+	CheckTemplate = "Template"
+	// CheckSecretData checks a projected Secret has the keys its type requires.
+	CheckSecretData = "SecretData"
+)
+
+// Verification is the result of verifying a Lockbox. It never includes unlocked values.
+type Verification struct {
+	// Valid is set if every check passed.
+	Valid  bool                `json:"valid"`
+	Checks []VerificationCheck `json:"checks"`
+}
+
+// VerificationCheck is the result of a single check made by Verify.
+type VerificationCheck struct {
+	Check string `json:"check"`
+
+	// Key is the data key or projection name the check applies to, if any.
+	Key    string `json:"key,omitempty"`
+	Passed bool   `json:"passed"`
+
+	// Reason is the Ready condition reason the controller would set if the check failed.
+	// Error messages aren't included, as they may contain unlocked values.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Verify makes the checks Reconcile makes before creating Secrets, reporting each rather than
+// stopping at the first failure. Neither the Lockbox's status nor any Secrets are changed.
+//
+// The Lockbox may come from an untrusted caller, so nothing computed from unlocked values
+// is revealed beyond fixed reasons: templates are parsed but never executed, and projected
+// Secrets are only checked for the keys their type requires.
+func (s *SecretReconciler) Verify(lb *lockboxv1.Lockbox) *Verification {
+	v := &Verification{Valid: true}
+
+	opener, peerKey, lerr := s.opener(lb)
+	v.add(CheckPeerKey, "", lerr)
+	if lerr != nil {
+		return v
+	}
+
+	v.add(CheckNamespace, "", s.checkNamespace(lb, peerKey, opener))
+
+	keys := make([]string, 0, len(lb.Spec.Data))
+	for key := range lb.Spec.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	unlocked := true
+	data := make(map[string][]byte, len(lb.Spec.Data)+len(lb.Spec.Template.Data))
+	for _, key := range keys {
+		var lerr *lockboxError
+		if _, err := opener.Open(lb.Spec.Data[key]); err != nil {
+			lerr = &lockboxError{err: err, reason: "InvalidLockbox"}
+			unlocked = false
+		}
+		// Only whether each key is present matters to the checks below.
+		data[key] = nil
+		v.add(CheckDataKey, key, lerr)
+	}
+	if !unlocked {
+		return v
+	}
+
+	if len(lb.Spec.Template.Data) > 0 {
+		var lerr *lockboxError
+		if err := lockboxv1.ParseTemplates(lb.Spec.Template.Data); err != nil {
+			lerr = &lockboxError{err: err, reason: "TemplateError"}
+		}
+		v.add(CheckTemplate, "", lerr)
+		if lerr != nil {
+			return v
+		}
+	}
+	for key := range lb.Spec.Template.Data {
+		data[key] = nil
+	}
+
+	projections, err := lb.SecretProjections()
+	if err != nil {
+		v.add(CheckSecretData, "", &lockboxError{err: err, reason: "InvalidLockbox"})
+		return v
+	}
+	for i := range projections {
+		projection := &projections[i]
+		v.add(CheckSecretData, projection.Name, checkSecretKeys(projection, data))
+	}
+
+	return v
+}
+
+// checkSecretKeys checks the Secret projected from data has the keys and annotations its
+// type requires.
+func checkSecretKeys(projection *lockboxv1.LockboxSecretProjection, data map[string][]byte) *lockboxError {
+	secret := &corev1.Secret{}
+	if err := projection.ProjectInto(secret, data); err != nil {
+		return &lockboxError{err: err, reason: "InvalidLockbox"}
+	}
+
+	if missing := lockboxv1.MissingSecretKeys(secret.Type, secret.Data); len(missing) > 0 {
+		return &lockboxError{err: fmt.Errorf("missing keys: %v", missing), reason: "InvalidSecretData"}
+	}
+	if missing := lockboxv1.MissingSecretAnnotations(secret.Type, secret.Annotations); len(missing) > 0 {
+		return &lockboxError{err: fmt.Errorf("missing annotations: %v", missing), reason: "InvalidSecretData"}
+	}
+	return nil
+}
+
+// add records the result of a check, which failed if lerr is set.
+func (v *Verification) add(check, key string, lerr *lockboxError) {
+	c := VerificationCheck{Check: check, Key: key, Passed: lerr == nil}
+	if lerr != nil {
+		c.Reason = lerr.reason
+		v.Valid = false
+	}
+	v.Checks = append(v.Checks, c)
+}
@@ -0,0 +1,153 @@
+package controller_test
+
+import (
+	"testing"
+
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	controller "github.com/cloudflare/lockbox/pkg/lockbox-controller"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestSecretReconcilerVerify(t *testing.T) {
+	type testCase struct {
+		name     string
+		lockbox  func() *lockboxv1.Lockbox
+		expected controller.Verification
+	}
+
+	run := func(t *testing.T, tc testCase) {
+		pubKey, priKey, err := loadKeypair(t, "6a42b9fc2b011fb88c01741483e3bffe455bdab1ae35d0bb53a3c00d406d8836", "252173f975f0a0ddb198a7e5958c074203a0e9f44275e0b840f95d456c4acc2e")
+		assert.NilError(t, err)
+
+		sr := controller.NewSecretReconciler(pubKey, priKey)
+		assert.DeepEqual(t, *sr.Verify(tc.lockbox()), tc.expected)
+	}
+
+	passed := func(check, key string) controller.VerificationCheck {
+		return controller.VerificationCheck{Check: check, Key: key, Passed: true}
+	}
+
+	testCases := []testCase{
+		{
+			name: "valid",
+			lockbox: func() *lockboxv1.Lockbox {
+				return exampleLockbox(lockboxv1.LockboxSecretTemplate{})
+			},
+			expected: controller.Verification{
+				Valid: true,
+				Checks: []controller.VerificationCheck{
+					passed(controller.CheckPeerKey, ""),
+					passed(controller.CheckNamespace, ""),
+					passed(controller.CheckDataKey, "test"),
+					passed(controller.CheckDataKey, "test1"),
+					passed(controller.CheckSecretData, "example"),
+				},
+			},
+		},
+		{
+			name: "unknown peer key",
+			lockbox: func() *lockboxv1.Lockbox {
+				lb := exampleLockbox(lockboxv1.LockboxSecretTemplate{})
+				lb.Spec.Peer = make([]byte, 32)
+				return lb
+			},
+			expected: controller.Verification{
+				Checks: []controller.VerificationCheck{
+					{
+						Check:  controller.CheckPeerKey,
+						Reason: controller.UnknownPeerKeyReason,
+					},
+				},
+			},
+		},
+		{
+			name: "wrong namespace and corrupt data",
+			lockbox: func() *lockboxv1.Lockbox {
+				lb := exampleLockbox(lockboxv1.LockboxSecretTemplate{})
+				lb.Namespace = "other"
+				lb.Spec.Data["test1"] = lb.Spec.Data["test1"][:30]
+				return lb
+			},
+			expected: controller.Verification{
+				Checks: []controller.VerificationCheck{
+					passed(controller.CheckPeerKey, ""),
+					{
+						Check:  controller.CheckNamespace,
+						Reason: "InvalidNamespace",
+					},
+					passed(controller.CheckDataKey, "test"),
+					{
+						Check:  controller.CheckDataKey,
+						Key:    "test1",
+						Reason: "InvalidLockbox",
+					},
+				},
+			},
+		},
+		{
+			name: "missing secret keys",
+			lockbox: func() *lockboxv1.Lockbox {
+				return exampleLockbox(lockboxv1.LockboxSecretTemplate{Type: corev1.SecretTypeTLS})
+			},
+			expected: controller.Verification{
+				Checks: []controller.VerificationCheck{
+					passed(controller.CheckPeerKey, ""),
+					passed(controller.CheckNamespace, ""),
+					passed(controller.CheckDataKey, "test"),
+					passed(controller.CheckDataKey, "test1"),
+					{
+						Check:  controller.CheckSecretData,
+						Key:    "example",
+						Reason: "InvalidSecretData",
+					},
+				},
+			},
+		},
+		{
+			name: "templates are parsed but not executed",
+			lockbox: func() *lockboxv1.Lockbox {
+				return exampleLockbox(lockboxv1.LockboxSecretTemplate{
+					Data: map[string]string{"missing": "{{ .notakey }}"},
+				})
+			},
+			expected: controller.Verification{
+				Valid: true,
+				Checks: []controller.VerificationCheck{
+					passed(controller.CheckPeerKey, ""),
+					passed(controller.CheckNamespace, ""),
+					passed(controller.CheckDataKey, "test"),
+					passed(controller.CheckDataKey, "test1"),
+					passed(controller.CheckTemplate, ""),
+					passed(controller.CheckSecretData, "example"),
+				},
+			},
+		},
+		{
+			name: "invalid template",
+			lockbox: func() *lockboxv1.Lockbox {
+				return exampleLockbox(lockboxv1.LockboxSecretTemplate{
+					Data: map[string]string{"broken": "{{ .test "},
+				})
+			},
+			expected: controller.Verification{
+				Checks: []controller.VerificationCheck{
+					passed(controller.CheckPeerKey, ""),
+					passed(controller.CheckNamespace, ""),
+					passed(controller.CheckDataKey, "test"),
+					passed(controller.CheckDataKey, "test1"),
+					{
+						Check:  controller.CheckTemplate,
+						Reason: "TemplateError",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			run(t, tc)
+		})
+	}
+}
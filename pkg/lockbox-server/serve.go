@@ -1,15 +1,110 @@
 package server
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"github.com/cloudflare/lockbox/pkg/keyring"
 	"github.com/kevinburke/nacl"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
-// PublicKey creates an HTTP handler that responses with the specified public key
-// as binary data.
-func PublicKey(pubKey nacl.Key) http.Handler {
+// PublicKeySource provides the public key currently used to lock new Lockboxes.
+type PublicKeySource interface {
+	PublicKey() nacl.Key
+}
+
+// NamespacePublicKeySource is a PublicKeySource that also provides public keys derived
+// for individual namespaces.
+type NamespacePublicKeySource interface {
+	PublicKeySource
+	NamespacePublicKey(namespace string) (nacl.Key, error)
+}
+
+// HybridPublicKeySource provides Curve25519 and ML-KEM-768 hybrid public keys, optionally
+// derived for a namespace.
+type HybridPublicKeySource interface {
+	HybridPublicKey(namespace string) ([]byte, error)
+}
+
+// PublicKey creates an HTTP handler that responses with the source's public key
+// as binary data. If the source provides namespace keys, the "namespace" query
+// parameter selects the public key derived for that namespace. The "algorithm" query
+// parameter selects the hybrid public key for Lockboxes using the
+// lockboxv1.AlgorithmX25519MLKEM768 algorithm. When namespace keys can't be derived because
+// the private key is held outside of memory, the underived key is served instead, and
+// Lockboxes sealed for it rely on the sealed namespace alone.
+func PublicKey(keys PublicKeySource) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pubKey := keys.PublicKey()
+
+		namespace := r.URL.Query().Get("namespace")
+		if namespace != "" {
+			if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+				http.Error(w, "invalid namespace: "+strings.Join(errs, "; "), http.StatusBadRequest)
+				return
+			}
+		}
+
+		switch algorithm := r.URL.Query().Get("algorithm"); algorithm {
+		case "", lockboxv1.AlgorithmNaClBox:
+		case lockboxv1.AlgorithmX25519MLKEM768:
+			hybridKeys, ok := keys.(HybridPublicKeySource)
+			if !ok {
+				http.Error(w, "hybrid keys are not supported", http.StatusBadRequest)
+				return
+			}
+
+			hybridKey, err := hybridKeys.HybridPublicKey(namespace)
+			if errors.Is(err, keyring.ErrNoNamespaceKeys) {
+				hybridKey, err = hybridKeys.HybridPublicKey("")
+			}
+			if errors.Is(err, lockboxv1.ErrNoDecapsulationKey) {
+				http.Error(w, "hybrid keys are not supported", http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				http.Error(w, "unable to derive hybrid key", http.StatusInternalServerError)
+				return
+			}
+			if hybridKey == nil {
+				http.Error(w, "no public key available", http.StatusServiceUnavailable)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = w.Write(hybridKey)
+			return
+		default:
+			http.Error(w, "unsupported algorithm: "+algorithm, http.StatusBadRequest)
+			return
+		}
+
+		if namespace != "" {
+			nsKeys, ok := keys.(NamespacePublicKeySource)
+			if !ok {
+				http.Error(w, "namespace keys are not supported", http.StatusBadRequest)
+				return
+			}
+
+			nsKey, err := nsKeys.NamespacePublicKey(namespace)
+			if errors.Is(err, keyring.ErrNoNamespaceKeys) {
+				nsKey, err = pubKey, nil
+			}
+			if err != nil {
+				http.Error(w, "unable to derive namespace key", http.StatusInternalServerError)
+				return
+			}
+			pubKey = nsKey
+		}
+
+		if pubKey == nil {
+			http.Error(w, "no public key available", http.StatusServiceUnavailable)
+			return
+		}
+
 		w.Header().Set("Content-Type", "application/octet-stream")
 		_, _ = w.Write(pubKey[:])
 	})
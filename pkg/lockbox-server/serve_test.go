@@ -0,0 +1,113 @@
+package server_test
+
+import (
+	"crypto/rand"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"github.com/cloudflare/lockbox/pkg/keyring"
+	server "github.com/cloudflare/lockbox/pkg/lockbox-server"
+	"github.com/kevinburke/nacl/box"
+	"gotest.tools/v3/assert"
+)
+
+func TestPublicKey(t *testing.T) {
+	pub, pri, err := box.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+	mlkemSeed, err := keyring.GenerateMLKEMSeed()
+	assert.NilError(t, err)
+	keys := keyring.New(keyring.KeyPair{Public: pub, Private: keyring.StaticHybridKey(pri, mlkemSeed)})
+	nsPub, _, err := keyring.DeriveNamespaceKey(keyring.StaticKey(pri), "example")
+	assert.NilError(t, err)
+	hybridPub, err := lockboxv1.HybridPublicKey(pub, keyring.StaticHybridKey(pri, mlkemSeed))
+	assert.NilError(t, err)
+	nsHybridPub, err := keys.HybridPublicKey("example")
+	assert.NilError(t, err)
+
+	type testCase struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedBody   []byte
+	}
+
+	run := func(t *testing.T, tc testCase) {
+		rec := httptest.NewRecorder()
+		server.PublicKey(keys).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/public"+tc.query, nil))
+
+		assert.Equal(t, rec.Code, tc.expectedStatus)
+		if tc.expectedBody != nil {
+			body, err := io.ReadAll(rec.Body)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, body, tc.expectedBody)
+		}
+	}
+
+	testCases := []testCase{
+		{
+			name:           "public key",
+			expectedStatus: http.StatusOK,
+			expectedBody:   pub[:],
+		},
+		{
+			name:           "namespace key",
+			query:          "?namespace=example",
+			expectedStatus: http.StatusOK,
+			expectedBody:   nsPub[:],
+		},
+		{
+			name:           "invalid namespace",
+			query:          "?namespace=Not_A_Namespace",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "hybrid key",
+			query:          "?algorithm=x25519-mlkem768",
+			expectedStatus: http.StatusOK,
+			expectedBody:   hybridPub,
+		},
+		{
+			name:           "namespace hybrid key",
+			query:          "?namespace=example&algorithm=x25519-mlkem768",
+			expectedStatus: http.StatusOK,
+			expectedBody:   nsHybridPub,
+		},
+		{
+			name:           "unsupported algorithm",
+			query:          "?algorithm=rot13",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			run(t, tc)
+		})
+	}
+}
+
+// externalKey is a KeyProvider whose private key isn't held in memory.
+type externalKey struct {
+	keyring.KeyProvider
+}
+
+func TestPublicKey_ExternalKey(t *testing.T) {
+	pub, pri, err := box.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+	keys := keyring.New(keyring.KeyPair{Public: pub, Private: externalKey{keyring.StaticKey(pri)}})
+
+	rec := httptest.NewRecorder()
+	server.PublicKey(keys).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/public?namespace=example", nil))
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+	body, err := io.ReadAll(rec.Body)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, body, pub[:])
+
+	rec = httptest.NewRecorder()
+	server.PublicKey(keys).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/public?namespace=example&algorithm=x25519-mlkem768", nil))
+	assert.Equal(t, rec.Code, http.StatusBadRequest)
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Server serves the controller's HTTP API, optionally over TLS.
+type Server struct {
+	addr     string
+	handler  http.Handler
+	certFile string
+	keyFile  string
+}
+
+// ServerOption allows for functional options to modify the Server
+type ServerOption func(s *Server)
+
+// WithTLS serves HTTPS using the certificate and key files, reloading them whenever they
+// change.
+func WithTLS(certFile, keyFile string) ServerOption {
+	return func(s *Server) {
+		s.certFile = certFile
+		s.keyFile = keyFile
+	}
+}
+
+// NewServer creates a Server for the handler, listening on the TCP address.
+func NewServer(addr string, handler http.Handler, options ...ServerOption) *Server {
+	s := &Server{
+		addr:    addr,
+		handler: handler,
+	}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	return s
+}
+
+// Start implements manager.Runnable by serving on the Server's address until the context
+// is cancelled.
+func (s *Server) Start(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(ctx, ln)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every replica serves, as
+// requests are balanced across them.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Serve accepts connections on the listener until the context is cancelled, then shuts
+// down gracefully.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	log := logf.FromContext(ctx).WithName("server")
+
+	if s.certFile != "" {
+		watcher, err := certwatcher.New(s.certFile, s.keyFile)
+		if err != nil {
+			ln.Close()
+			return err
+		}
+
+		go func() {
+			if err := watcher.Start(ctx); err != nil {
+				log.Error(err, "unable to watch certificate")
+			}
+		}()
+
+		ln = tls.NewListener(ln, &tls.Config{
+			GetCertificate: watcher.GetCertificate,
+			MinVersion:     tls.VersionTLS12,
+		})
+	}
+
+	// sig.kubernetes.io/controller-runtime/pkg/internal/httpserver
+	srv := http.Server{
+		Handler:           s.handler,
+		MaxHeaderBytes:    1 << 20,
+		IdleTimeout:       90 * time.Second,
+		ReadHeaderTimeout: 32 * time.Second,
+	}
+
+	idleConnsClosed := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+
+		if err := srv.Shutdown(context.Background()); err != nil {
+			log.Error(err, "unable to shut down server")
+		}
+		close(idleConnsClosed)
+	}()
+
+	if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+
+	<-idleConnsClosed
+	return nil
+}
@@ -0,0 +1,103 @@
+package server_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	server "github.com/cloudflare/lockbox/pkg/lockbox-server"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
+)
+
+func TestServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+
+	done := make(chan error)
+	go func() { done <- server.NewServer("", handler).Serve(ctx, ln) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String())
+	assert.NilError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.NilError(t, err)
+	assert.Equal(t, string(body), "ok")
+
+	cancel()
+	assert.NilError(t, <-done)
+}
+
+func TestServer_TLS(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	first := writeCertificate(t, certFile, keyFile, 1)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+
+	handler := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	go func() { _ = server.NewServer("", handler, server.WithTLS(certFile, keyFile)).Serve(ctx, ln) }()
+
+	serial := func() *big.Int {
+		conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+		assert.NilError(t, err)
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].SerialNumber
+	}
+	assert.Equal(t, serial().Cmp(first), 0)
+
+	second := writeCertificate(t, certFile, keyFile, 2)
+	poll.WaitOn(t, func(poll.LogT) poll.Result {
+		if serial().Cmp(second) == 0 {
+			return poll.Success()
+		}
+		return poll.Continue("certificate not reloaded")
+	}, poll.WithTimeout(5*time.Second), poll.WithDelay(50*time.Millisecond))
+}
+
+// writeCertificate writes a self-signed certificate with the serial number and its key.
+func writeCertificate(t *testing.T, certFile, keyFile string, serial int64) *big.Int {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "lockbox"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NilError(t, err)
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NilError(t, err)
+
+	assert.NilError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600))
+	assert.NilError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	return template.SerialNumber
+}
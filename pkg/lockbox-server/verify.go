@@ -0,0 +1,56 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	controller "github.com/cloudflare/lockbox/pkg/lockbox-controller"
+	"sigs.k8s.io/yaml"
+)
+
+// maxLockboxSize limits the size of Lockbox manifests accepted for verification.
+const maxLockboxSize = 1 << 20
+
+// Verifier checks whether Lockboxes can be unlocked, without unlocking them.
+type Verifier interface {
+	Verify(lb *lockboxv1.Lockbox) *controller.Verification
+}
+
+// Verify creates an HTTP handler that verifies the Lockbox manifest, as YAML or JSON, in
+// a POST request body. It responds with the JSON encoded controller.Verification, which
+// never includes unlocked values or anything computed from them, only fixed reasons for
+// each failed check.
+func Verify(v Verifier) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxLockboxSize))
+		if err != nil {
+			http.Error(w, "unable to read lockbox", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		lb := &lockboxv1.Lockbox{}
+		if err := yaml.Unmarshal(body, lb); err != nil {
+			http.Error(w, "invalid lockbox", http.StatusBadRequest)
+			return
+		}
+		if lb.Kind != "Lockbox" || lb.APIVersion != lockboxv1.GroupVersion.String() {
+			http.Error(w, "invalid lockbox: expected "+lockboxv1.GroupVersion.String()+" Lockbox", http.StatusBadRequest)
+			return
+		}
+		if lb.Namespace == "" {
+			http.Error(w, "invalid lockbox: missing namespace", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(v.Verify(lb))
+	})
+}
@@ -0,0 +1,88 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	controller "github.com/cloudflare/lockbox/pkg/lockbox-controller"
+	server "github.com/cloudflare/lockbox/pkg/lockbox-server"
+	"gotest.tools/v3/assert"
+)
+
+// namespaceVerifier reports whether Lockboxes are in the expected namespace.
+type namespaceVerifier string
+
+func (v namespaceVerifier) Verify(lb *lockboxv1.Lockbox) *controller.Verification {
+	return &controller.Verification{
+		Valid:  lb.Namespace == string(v),
+		Checks: []controller.VerificationCheck{{Check: controller.CheckNamespace, Passed: lb.Namespace == string(v)}},
+	}
+}
+
+func TestVerify(t *testing.T) {
+	type testCase struct {
+		name           string
+		method         string
+		body           string
+		expectedStatus int
+		expectedValid  bool
+	}
+
+	run := func(t *testing.T, tc testCase) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(tc.method, "/v1/verify", strings.NewReader(tc.body))
+		server.Verify(namespaceVerifier("example")).ServeHTTP(rec, req)
+
+		assert.Equal(t, rec.Code, tc.expectedStatus)
+		if tc.expectedStatus != http.StatusOK {
+			return
+		}
+
+		v := controller.Verification{}
+		assert.NilError(t, json.NewDecoder(rec.Body).Decode(&v))
+		assert.Equal(t, v.Valid, tc.expectedValid)
+	}
+
+	testCases := []testCase{
+		{
+			name:           "valid yaml",
+			method:         http.MethodPost,
+			body:           "apiVersion: lockbox.k8s.cloudflare.com/v1\nkind: Lockbox\nmetadata:\n  name: example\n  namespace: example\n",
+			expectedStatus: http.StatusOK,
+			expectedValid:  true,
+		},
+		{
+			name:           "invalid json",
+			method:         http.MethodPost,
+			body:           `{"apiVersion": "lockbox.k8s.cloudflare.com/v1", "kind": "Lockbox", "metadata": {"name": "example", "namespace": "other"}}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing namespace",
+			method:         http.MethodPost,
+			body:           "apiVersion: lockbox.k8s.cloudflare.com/v1\nkind: Lockbox\nmetadata:\n  name: example\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "not a lockbox",
+			method:         http.MethodPost,
+			body:           "apiVersion: v1\nkind: Secret\nmetadata:\n  name: example\n  namespace: example\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "get",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			run(t, tc)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// Package shamir implements Shamir's secret sharing over GF(2^8), splitting a secret into
+// shares such that any threshold of them reconstruct it, while fewer reveal nothing.
+package shamir
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// Share is one share of a secret.
+type Share struct {
+	// X is the share's non-zero evaluation point.
+	X byte
+
+	// Y holds the value of each byte's polynomial at X.
+	Y []byte
+}
+
+// Split divides the secret into n shares, any k of which can reconstruct it.
+func Split(secret []byte, n, k int) ([]Share, error) {
+	switch {
+	case len(secret) == 0:
+		return nil, errors.New("secret must not be empty")
+	case k < 2:
+		return nil, errors.New("threshold must be at least 2")
+	case n < k:
+		return nil, errors.New("number of shares must be at least the threshold")
+	case n > 255:
+		return nil, errors.New("number of shares must be at most 255")
+	}
+
+	shares := make([]Share, n)
+	for i := range shares {
+		shares[i] = Share{X: byte(i + 1), Y: make([]byte, len(secret))}
+	}
+
+	// Each byte of the secret is the constant term of a random polynomial of degree k-1.
+	coefficients := make([]byte, k)
+	for j, b := range secret {
+		coefficients[0] = b
+		if _, err := rand.Read(coefficients[1:]); err != nil {
+			return nil, err
+		}
+
+		for i := range shares {
+			shares[i].Y[j] = evaluate(coefficients, shares[i].X)
+		}
+	}
+
+	return shares, nil
+}
+
+// Combine reconstructs a secret from at least threshold shares. With fewer shares, the
+// result is unrelated to the secret.
+func Combine(shares []Share) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, errors.New("at least 2 shares are required")
+	}
+
+	seen := make(map[byte]bool, len(shares))
+	for _, s := range shares {
+		if s.X == 0 {
+			return nil, errors.New("share has invalid index 0")
+		}
+		if seen[s.X] {
+			return nil, fmt.Errorf("duplicate share %d", s.X)
+		}
+		if len(s.Y) != len(shares[0].Y) {
+			return nil, errors.New("shares have different lengths")
+		}
+		seen[s.X] = true
+	}
+
+	// Lagrange interpolation at x = 0.
+	secret := make([]byte, len(shares[0].Y))
+	for i, si := range shares {
+		basis := byte(1)
+		for j, sj := range shares {
+			if i == j {
+				continue
+			}
+			// sj.X / (sj.X - si.X), where subtraction is XOR.
+			basis = mul(basis, div(sj.X, sj.X^si.X))
+		}
+
+		for b := range secret {
+			secret[b] ^= mul(si.Y[b], basis)
+		}
+	}
+
+	return secret, nil
+}
+
+// evaluate returns the polynomial's value at x, using Horner's method.
+func evaluate(coefficients []byte, x byte) byte {
+	var y byte
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		y = mul(y, x) ^ coefficients[i]
+	}
+	return y
+}
+
+// mul multiplies in GF(2^8) with the AES polynomial, without data dependent branches.
+func mul(a, b byte) byte {
+	var p byte
+	for i := 0; i < 8; i++ {
+		p ^= a & -(b & 1)
+		carry := -(a >> 7)
+		a = a<<1 ^ 0x1b&carry
+		b >>= 1
+	}
+	return p
+}
+
+// div divides in GF(2^8), with b non-zero.
+func div(a, b byte) byte {
+	return mul(a, inverse(b))
+}
+
+// inverse returns b^254, the multiplicative inverse of non-zero b.
+func inverse(b byte) byte {
+	result := byte(1)
+	for e := 254; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			result = mul(result, b)
+		}
+		b = mul(b, b)
+	}
+	return result
+}
@@ -0,0 +1,75 @@
+package shamir
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestMul(t *testing.T) {
+	// Known products under the AES polynomial.
+	assert.Equal(t, mul(0x57, 0x83), byte(0xc1))
+	assert.Equal(t, mul(0x57, 0x13), byte(0xfe))
+
+	for b := 1; b < 256; b++ {
+		assert.Equal(t, mul(byte(b), inverse(byte(b))), byte(1), "b = %d", b)
+	}
+}
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("correct horse battery staple....")
+
+	shares, err := Split(secret, 5, 3)
+	assert.NilError(t, err)
+	assert.Equal(t, len(shares), 5)
+
+	type testCase struct {
+		shares []Share
+		match  bool
+	}
+
+	run := func(t *testing.T, tc testCase) {
+		combined, err := Combine(tc.shares)
+		assert.NilError(t, err)
+		assert.Equal(t, bytes.Equal(combined, secret), tc.match)
+	}
+
+	testCases := map[string]testCase{
+		"threshold": {
+			shares: []Share{shares[0], shares[2], shares[4]},
+			match:  true,
+		},
+		"all": {
+			shares: shares,
+			match:  true,
+		},
+		"reordered": {
+			shares: []Share{shares[3], shares[1], shares[0]},
+			match:  true,
+		},
+		"below threshold": {
+			shares: []Share{shares[0], shares[1]},
+			match:  false,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			run(t, tc)
+		})
+	}
+}
+
+func TestSplitInvalid(t *testing.T) {
+	_, err := Split([]byte("secret"), 2, 3)
+	assert.ErrorContains(t, err, "at least the threshold")
+
+	_, err = Split([]byte("secret"), 3, 1)
+	assert.ErrorContains(t, err, "threshold must be at least 2")
+
+	shares, err := Split([]byte("secret"), 3, 2)
+	assert.NilError(t, err)
+	_, err = Combine([]Share{shares[0], shares[0]})
+	assert.ErrorContains(t, err, "duplicate share")
+}
@@ -3,7 +3,9 @@ package statemetrics
 import (
 	"sync"
 
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
 	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -179,3 +181,84 @@ func (v *LabelsVec) Collect(ch chan<- prometheus.Metric) {
 		ch <- metric
 	}
 }
+
+// conditionStatuses are the possible statuses of a condition. A metric is
+// exported for each, with a value of 1 for the current status.
+var conditionStatuses = []corev1.ConditionStatus{corev1.ConditionTrue, corev1.ConditionFalse, corev1.ConditionUnknown}
+
+// ConditionsVec is a Collector that bundles a set of Kubernetes metrics describing status conditions,
+// in the style of kube-state-metrics. Each condition is exported as one metric per possible status,
+// partitioned by the "condition", "status", and "reason" labels. Create instances with
+// NewConditionsVec.
+type ConditionsVec struct {
+	desc    *prometheus.Desc
+	metrics map[types.UID][]Kubernetes
+	mu      sync.Mutex
+}
+
+// NewConditionsVec creates a new ConditionsVec based on the provided KubernetesOpts and partitioned
+// by the given label names, in addition to the condition labels.
+func NewConditionsVec(opts KubernetesOpts, labelNames []string) *ConditionsVec {
+	desc := prometheus.NewDesc(
+		prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name),
+		opts.Help,
+		append(labelNames[:len(labelNames):len(labelNames)], "condition", "status", "reason"),
+		opts.ConstLabels,
+	)
+
+	return &ConditionsVec{
+		desc:    desc,
+		metrics: make(map[types.UID][]Kubernetes),
+	}
+}
+
+// Set replaces the metrics for the given uid with the provided conditions and slice of label
+// values (in the same order as the variable labels).
+func (v *ConditionsVec) Set(uid types.UID, conditions []lockboxv1.Condition, lvs ...string) {
+	metrics := make([]Kubernetes, 0, len(conditions)*len(conditionStatuses))
+	for _, condition := range conditions {
+		for _, status := range conditionStatuses {
+			values := append(lvs[:len(lvs):len(lvs)], string(condition.Type), string(status), condition.Reason)
+			k := &kubernetes{
+				values: values,
+				desc:   v.desc,
+			}
+			if condition.Status == status {
+				k.Set(1)
+			} else {
+				k.Set(0)
+			}
+
+			metrics = append(metrics, k)
+		}
+	}
+
+	v.mu.Lock()
+	v.metrics[uid] = metrics
+	v.mu.Unlock()
+}
+
+// Delete deletes the metrics stored for this uid.
+func (v *ConditionsVec) Delete(uid types.UID) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	delete(v.metrics, uid)
+}
+
+// Describe implements Collector.
+func (v *ConditionsVec) Describe(ch chan<- *prometheus.Desc) {
+	ch <- v.desc
+}
+
+// Collect implements Collector.
+func (v *ConditionsVec) Collect(ch chan<- prometheus.Metric) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for _, metrics := range v.metrics {
+		for _, metric := range metrics {
+			ch <- metric
+		}
+	}
+}
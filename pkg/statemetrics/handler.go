@@ -21,11 +21,19 @@ type StateMetricProxy struct {
 	lbType          *KubernetesVec
 	peerKey         *KubernetesVec
 	labels          *LabelsVec
+	status          *StatusRecorder
+
+	labelFilter      LabelFilter
+	annotations      *LabelsVec
+	annotationFilter LabelFilter
 }
 
+// StateMetricProxyOption allows for functional options to modify the StateMetricProxy.
+type StateMetricProxyOption func(s *StateMetricProxy)
+
 // NewStateMetricProxy returns a StateMetricsProxy. All metrics must be non-nil.
-func NewStateMetricProxy(enqueuer handler.EventHandler, info, created, resourceVersion, lbType, peerKey *KubernetesVec, labels *LabelsVec) *StateMetricProxy {
-	return &StateMetricProxy{
+func NewStateMetricProxy(enqueuer handler.EventHandler, info, created, resourceVersion, lbType, peerKey *KubernetesVec, labels *LabelsVec, options ...StateMetricProxyOption) *StateMetricProxy {
+	s := &StateMetricProxy{
 		enqueuer:        enqueuer,
 		info:            info,
 		created:         created,
@@ -33,6 +41,37 @@ func NewStateMetricProxy(enqueuer handler.EventHandler, info, created, resourceV
 		lbType:          lbType,
 		peerKey:         peerKey,
 		labels:          labels,
+		labelFilter:     AllowAllLabels,
+	}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	return s
+}
+
+// WithLabelFilter sets the filter selecting which Kubernetes labels are exported. By default,
+// all labels are exported.
+func WithLabelFilter(f LabelFilter) StateMetricProxyOption {
+	return func(s *StateMetricProxy) {
+		s.labelFilter = f
+	}
+}
+
+// WithAnnotations exports the Kubernetes annotations selected by the filter as Prometheus
+// labels of the provided metric.
+func WithAnnotations(annotations *LabelsVec, f LabelFilter) StateMetricProxyOption {
+	return func(s *StateMetricProxy) {
+		s.annotations = annotations
+		s.annotationFilter = f
+	}
+}
+
+// WithStatusRecorder deletes the metrics of the StatusRecorder when Lockboxes are deleted.
+func WithStatusRecorder(r *StatusRecorder) StateMetricProxyOption {
+	return func(s *StateMetricProxy) {
+		s.status = r
 	}
 }
 
@@ -64,6 +103,12 @@ func (s *StateMetricProxy) Delete(ctx context.Context, evt event.DeleteEvent, q
 	s.lbType.Delete(uid)
 	s.peerKey.Delete(uid)
 	s.labels.Delete(uid)
+	if s.annotations != nil {
+		s.annotations.Delete(uid)
+	}
+	if s.status != nil {
+		s.status.Delete(uid)
+	}
 
 	if s.enqueuer != nil {
 		s.enqueuer.Delete(ctx, evt, q)
@@ -95,9 +140,17 @@ func (s *StateMetricProxy) updateWith(obj client.Object) {
 		s.peerKey.WithLabelValues(uid, namespace, lockbox, hex.EncodeToString(lb.Spec.Peer)).Set(1)
 	}
 
-	promLabels := kubernetesLabelsToPrometheusLabels(obj.GetLabels())
+	promLabels := kubernetesLabelsToPrometheusLabels(obj.GetLabels(), "label_", s.labelFilter)
 	promLabels["namespace"] = namespace
 	promLabels["lockbox"] = lockbox
 
 	s.labels.With(uid, promLabels).Set(1)
+
+	if s.annotations != nil {
+		promAnnotations := kubernetesLabelsToPrometheusLabels(obj.GetAnnotations(), "annotation_", s.annotationFilter)
+		promAnnotations["namespace"] = namespace
+		promAnnotations["lockbox"] = lockbox
+
+		s.annotations.With(uid, promAnnotations).Set(1)
+	}
 }
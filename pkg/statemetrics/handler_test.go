@@ -187,6 +187,51 @@ func TestStateMetricsProxy_Delete(t *testing.T) {
 	}
 }
 
+func TestStateMetricsProxy_LabelFilters(t *testing.T) {
+	lb := &lockboxv1.Lockbox{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "fizz",
+			Name:      "buzz",
+			UID:       "foobar",
+			Labels: map[string]string{
+				"testing":           "true",
+				"pod-template-hash": "abcdef",
+			},
+			Annotations: map[string]string{
+				"owner":       "security",
+				"description": "unbounded",
+			},
+		},
+	}
+	info, created, resourceVersion, lbType, peerKey, labels := createMetricVectors(t)
+	annotations := NewLabelsVec(KubernetesOpts{
+		Name: "kube_lockbox_annotations",
+		Help: "Kubernetes annotations converted to Prometheus labels",
+	})
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(labels, annotations)
+
+	handler := NewStateMetricProxy(nil, info, created, resourceVersion, lbType, peerKey, labels,
+		WithLabelFilter(NewLabelFilter([]string{"*"}, []string{"pod-template-hash"})),
+		WithAnnotations(annotations, NewLabelFilter([]string{"owner"}, nil)),
+	)
+	handler.Create(context.Background(), event.CreateEvent{Object: lb}, nil)
+
+	expected := strings.NewReader(`
+# HELP kube_lockbox_labels Kubernetes labels converted to Prometheus labels
+# TYPE kube_lockbox_labels gauge
+kube_lockbox_labels{label_testing="true",lockbox="buzz",namespace="fizz"} 1
+# HELP kube_lockbox_annotations Kubernetes annotations converted to Prometheus labels
+# TYPE kube_lockbox_annotations gauge
+kube_lockbox_annotations{annotation_owner="security",lockbox="buzz",namespace="fizz"} 1
+`)
+
+	if err := testutil.GatherAndCompare(reg, expected); err != nil {
+		t.Error(err)
+	}
+}
+
 func createMetricVectors(t *testing.T) (info, created, resourceVersion, lbType, peerKey *KubernetesVec, labels *LabelsVec) {
 	info = NewKubernetesVec(KubernetesOpts{
 		Name: "kube_lockbox_info",
@@ -1,24 +1,96 @@
 package statemetrics
 
 import (
+	"fmt"
 	"regexp"
+	"sort"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var invalidLabelCharRE = regexp.MustCompile(`[^a-zA-Z0-9_]`)
 
+// LabelFilter selects which Kubernetes label or annotation keys are converted to Prometheus
+// labels, similar to kube-state-metrics' --metric-labels-allowlist. The zero value selects
+// no keys.
+type LabelFilter struct {
+	allowAll bool
+	allow    map[string]struct{}
+	deny     map[string]struct{}
+}
+
+// AllowAllLabels is a LabelFilter selecting every key.
+var AllowAllLabels = NewLabelFilter([]string{"*"}, nil)
+
+// NewLabelFilter creates a LabelFilter selecting keys in the allow list, except for those in
+// the deny list. An allow list containing "*" selects all keys not denied.
+func NewLabelFilter(allow, deny []string) LabelFilter {
+	f := LabelFilter{
+		allow: make(map[string]struct{}, len(allow)),
+		deny:  make(map[string]struct{}, len(deny)),
+	}
+
+	for _, key := range allow {
+		if key == "*" {
+			f.allowAll = true
+		}
+		f.allow[key] = struct{}{}
+	}
+	for _, key := range deny {
+		f.deny[key] = struct{}{}
+	}
+
+	return f
+}
+
+// Allowed reports whether the key should be converted to a Prometheus label.
+func (f LabelFilter) Allowed(key string) bool {
+	if _, ok := f.deny[key]; ok {
+		return false
+	}
+	if f.allowAll {
+		return true
+	}
+
+	_, ok := f.allow[key]
+	return ok
+}
+
 // sanitizeLabel replaces non-alphanumeric characters with underscores.
 func sanitizeLabel(l string) string {
 	return invalidLabelCharRE.ReplaceAllString(l, "_")
 }
 
-// kubernetesLabelsToPrometheusLabels generates Prometheus-safe labels from
-// the resource's Kubernetes labels.
-func kubernetesLabelsToPrometheusLabels(labels map[string]string) prometheus.Labels {
+// kubernetesLabelsToPrometheusLabels generates Prometheus-safe labels from the resource's
+// Kubernetes labels or annotations selected by the filter, with each label name prefixed by
+// the provided prefix.
+//
+// Distinct keys may sanitize to the same label name, such as "app.kubernetes.io" and
+// "app_kubernetes_io". Keys are converted in sorted order, and any later colliding
+// label names are suffixed with "_conflictN".
+func kubernetesLabelsToPrometheusLabels(labels map[string]string, prefix string, filter LabelFilter) prometheus.Labels {
+	keys := make([]string, 0, len(labels))
+	for l := range labels {
+		if filter.Allowed(l) {
+			keys = append(keys, l)
+		}
+	}
+	sort.Strings(keys)
+
 	promLabels := map[string]string{}
-	for l, v := range labels {
-		promLabels["label_"+sanitizeLabel(l)] = v
+	for _, l := range keys {
+		name := prefix + sanitizeLabel(l)
+		if _, ok := promLabels[name]; ok {
+			for i := 1; ; i++ {
+				conflict := fmt.Sprintf("%s_conflict%d", name, i)
+				if _, ok := promLabels[conflict]; !ok {
+					name = conflict
+					break
+				}
+			}
+		}
+
+		promLabels[name] = labels[l]
 	}
 
 	return promLabels
@@ -5,14 +5,16 @@ import (
 	"testing"
 	"testing/quick"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
+	"gotest.tools/v3/assert"
 )
 
 const reservedLabelPrefix = "__"
 
 func TestLabelsTransformation(t *testing.T) {
 	f := func(labels map[string]string) bool {
-		newLabels := kubernetesLabelsToPrometheusLabels(labels)
+		newLabels := kubernetesLabelsToPrometheusLabels(labels, "label_", AllowAllLabels)
 
 		for k := range newLabels {
 			if !checkLabelName(k) {
@@ -31,3 +33,65 @@ func TestLabelsTransformation(t *testing.T) {
 func checkLabelName(l string) bool {
 	return model.LabelName(l).IsValid() && !strings.HasPrefix(l, reservedLabelPrefix)
 }
+
+func TestLabelsCollisions(t *testing.T) {
+	labels := map[string]string{
+		"app.kubernetes.io/name": "dot",
+		"app_kubernetes_io/name": "underscore",
+		"app-kubernetes-io/name": "dash",
+	}
+
+	expected := prometheus.Labels{
+		"label_app_kubernetes_io_name":           "dash",
+		"label_app_kubernetes_io_name_conflict1": "dot",
+		"label_app_kubernetes_io_name_conflict2": "underscore",
+	}
+
+	assert.DeepEqual(t, kubernetesLabelsToPrometheusLabels(labels, "label_", AllowAllLabels), expected)
+}
+
+func TestLabelFilter(t *testing.T) {
+	labels := map[string]string{
+		"app":               "lockbox",
+		"team":              "security",
+		"pod-template-hash": "abcdef",
+	}
+
+	testCases := []struct {
+		name     string
+		filter   LabelFilter
+		expected prometheus.Labels
+	}{
+		{
+			name:     "zero value",
+			expected: prometheus.Labels{},
+		},
+		{
+			name:   "allow list",
+			filter: NewLabelFilter([]string{"app", "missing"}, nil),
+			expected: prometheus.Labels{
+				"label_app": "lockbox",
+			},
+		},
+		{
+			name:   "wildcard with deny list",
+			filter: NewLabelFilter([]string{"*"}, []string{"pod-template-hash"}),
+			expected: prometheus.Labels{
+				"label_app":  "lockbox",
+				"label_team": "security",
+			},
+		},
+		{
+			name:     "deny overrides allow",
+			filter:   NewLabelFilter([]string{"app"}, []string{"app"}),
+			expected: prometheus.Labels{},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.DeepEqual(t, kubernetesLabelsToPrometheusLabels(labels, "label_", tc.filter), tc.expected)
+		})
+	}
+}
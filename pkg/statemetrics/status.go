@@ -0,0 +1,51 @@
+package statemetrics
+
+import (
+	"time"
+
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"github.com/cloudflare/lockbox/pkg/util/conditions"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// StatusRecorder updates state metrics from the status set on Lockboxes during reconciliation,
+// rather than from watch events.
+type StatusRecorder struct {
+	conditions *ConditionsVec
+	lastUnlock *KubernetesVec
+	dataKeys   *KubernetesVec
+
+	now func() time.Time
+}
+
+// NewStatusRecorder returns a StatusRecorder. All metrics must be non-nil.
+func NewStatusRecorder(conditions *ConditionsVec, lastUnlock, dataKeys *KubernetesVec) *StatusRecorder {
+	return &StatusRecorder{
+		conditions: conditions,
+		lastUnlock: lastUnlock,
+		dataKeys:   dataKeys,
+		now:        time.Now,
+	}
+}
+
+// ObserveStatus updates the metrics for a Lockbox after its status conditions are set.
+func (s *StatusRecorder) ObserveStatus(lb *lockboxv1.Lockbox) {
+	namespace := lb.GetNamespace()
+	lockbox := lb.GetName()
+	uid := lb.GetUID()
+
+	s.conditions.Set(uid, lb.GetConditions(), namespace, lockbox)
+	s.dataKeys.WithLabelValues(uid, namespace, lockbox).Set(float64(len(lb.Spec.Data)))
+
+	if ready := conditions.Get(lb, lockboxv1.ReadyCondition); ready != nil && ready.Status == corev1.ConditionTrue {
+		s.lastUnlock.WithLabelValues(uid, namespace, lockbox).Set(float64(s.now().Unix()))
+	}
+}
+
+// Delete deletes the metrics stored for this uid.
+func (s *StatusRecorder) Delete(uid types.UID) {
+	s.conditions.Delete(uid)
+	s.lastUnlock.Delete(uid)
+	s.dataKeys.Delete(uid)
+}
@@ -0,0 +1,106 @@
+package statemetrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	lockboxv1 "github.com/cloudflare/lockbox/pkg/apis/lockbox.k8s.cloudflare.com/v1"
+	"github.com/cloudflare/lockbox/pkg/util/conditions"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestStatusRecorder(t *testing.T) {
+	lb := &lockboxv1.Lockbox{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "fizz",
+			Name:      "buzz",
+			UID:       "foobar",
+		},
+		Spec: lockboxv1.LockboxSpec{
+			Data: map[string][]byte{
+				"username": {0x00},
+				"password": {0x00},
+			},
+		},
+	}
+	statusCondition, lastUnlock, dataKeys := createStatusVectors(t)
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(statusCondition, lastUnlock, dataKeys)
+
+	recorder := NewStatusRecorder(statusCondition, lastUnlock, dataKeys)
+	recorder.now = func() time.Time { return time.Unix(1e9, 0) }
+
+	conditions.Set(lb, conditions.TrueCondition(lockboxv1.ReadyCondition))
+	recorder.ObserveStatus(lb)
+
+	recorder.now = func() time.Time { return time.Unix(2e9, 0) }
+	conditions.Set(lb, conditions.FalseCondition(lockboxv1.ReadyCondition, "UnknownPeerKey", lockboxv1.ConditionSeverityError, "unknown"))
+	recorder.ObserveStatus(lb)
+
+	expected := strings.NewReader(`
+# HELP kube_lockbox_status_condition The condition of a Lockbox
+# TYPE kube_lockbox_status_condition gauge
+kube_lockbox_status_condition{condition="Ready",lockbox="buzz",namespace="fizz",reason="UnknownPeerKey",status="False"} 1
+kube_lockbox_status_condition{condition="Ready",lockbox="buzz",namespace="fizz",reason="UnknownPeerKey",status="True"} 0
+kube_lockbox_status_condition{condition="Ready",lockbox="buzz",namespace="fizz",reason="UnknownPeerKey",status="Unknown"} 0
+# HELP kube_lockbox_last_unlock_timestamp Unix timestamp of the last successful unlock
+# TYPE kube_lockbox_last_unlock_timestamp gauge
+kube_lockbox_last_unlock_timestamp{lockbox="buzz",namespace="fizz"} 1e9
+# HELP kube_lockbox_data_keys Number of sealed data keys
+# TYPE kube_lockbox_data_keys gauge
+kube_lockbox_data_keys{lockbox="buzz",namespace="fizz"} 2
+`)
+
+	if err := testutil.GatherAndCompare(reg, expected); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStatusRecorder_Delete(t *testing.T) {
+	lb := &lockboxv1.Lockbox{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "fizz",
+			Name:      "buzz",
+			UID:       "foobar",
+		},
+	}
+	info, created, resourceVersion, lbType, peerKey, labels := createMetricVectors(t)
+	statusCondition, lastUnlock, dataKeys := createStatusVectors(t)
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(statusCondition, lastUnlock, dataKeys)
+
+	recorder := NewStatusRecorder(statusCondition, lastUnlock, dataKeys)
+	conditions.Set(lb, conditions.TrueCondition(lockboxv1.ReadyCondition))
+	recorder.ObserveStatus(lb)
+
+	handler := NewStateMetricProxy(nil, info, created, resourceVersion, lbType, peerKey, labels, WithStatusRecorder(recorder))
+	handler.Delete(context.Background(), event.DeleteEvent{Object: lb}, nil)
+
+	if err := testutil.GatherAndCompare(reg, &strings.Reader{}); err != nil {
+		t.Error(err)
+	}
+}
+
+func createStatusVectors(t *testing.T) (statusCondition *ConditionsVec, lastUnlock, dataKeys *KubernetesVec) {
+	statusCondition = NewConditionsVec(KubernetesOpts{
+		Name: "kube_lockbox_status_condition",
+		Help: "The condition of a Lockbox",
+	}, []string{"namespace", "lockbox"})
+	lastUnlock = NewKubernetesVec(KubernetesOpts{
+		Name: "kube_lockbox_last_unlock_timestamp",
+		Help: "Unix timestamp of the last successful unlock",
+	}, []string{"namespace", "lockbox"})
+	dataKeys = NewKubernetesVec(KubernetesOpts{
+		Name: "kube_lockbox_data_keys",
+		Help: "Number of sealed data keys",
+	}, []string{"namespace", "lockbox"})
+
+	return
+}